@@ -0,0 +1,166 @@
+package smalldb
+
+import (
+	"reflect"
+	"time"
+)
+
+// CompareAndSwap stores newValue for key only if the current value equals oldValue,
+// reporting whether the swap happened. Missing keys never match.
+// Values are compared as WithEquality configures, with reflect.DeepEqual by
+// default, and any TTL on the key is preserved.
+func (db *DB[T]) CompareAndSwap(key string, oldValue, newValue T) (bool, error) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return false, err
+	}
+
+	db.purgeExpired(time.Now())
+	current, exists := db.data[key]
+	if !exists || !db.equal(current, oldValue) {
+		return false, nil
+	}
+	if err := db.validateValue(key, newValue); err != nil {
+		return false, err
+	}
+	if err := db.checkChange(map[string]T{key: newValue}); err != nil {
+		return false, err
+	}
+
+	saved := db.saveForRollback(key)
+	db.data[key] = newValue
+	if err := db.commit([]Event[T]{{Key: key, Value: newValue, Op: OpSet}}, nil); err != nil {
+		saved.restore()
+		return false, err
+	}
+	return true, nil
+}
+
+// SetIfAbsent stores value for key only if the key does not already exist,
+// reporting whether it was inserted.
+func (db *DB[T]) SetIfAbsent(key string, value T) (bool, error) {
+	_, loaded, err := db.GetOrSet(key, value)
+	if err != nil {
+		return false, err
+	}
+	return !loaded, nil
+}
+
+// GetOrSet returns the existing value for key if present. Otherwise it stores
+// and returns value. The loaded result is true if the value was already present.
+func (db *DB[T]) GetOrSet(key string, value T) (T, bool, error) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		var zero T
+		return zero, false, err
+	}
+
+	db.purgeExpired(time.Now())
+	if existing, exists := db.data[key]; exists {
+		return existing, true, nil
+	}
+	if err := db.validateValue(key, value); err != nil {
+		var zero T
+		return zero, false, err
+	}
+	if err := db.checkChange(map[string]T{key: value}); err != nil {
+		var zero T
+		return zero, false, err
+	}
+
+	saved := db.saveKeys([]string{key})
+	db.data[key] = value
+	if err := db.commit([]Event[T]{{Key: key, Value: value, Op: OpSet}}, nil); err != nil {
+		saved.restore()
+		var zero T
+		return zero, false, err
+	}
+	return value, false, nil
+}
+
+// Rename moves the value stored under oldKey to newKey, along with any TTL,
+// and persists once. An existing value under newKey is overwritten; use
+// RenameNX to refuse that. Returns ErrKeyNotFound if oldKey does not exist.
+func (db *DB[T]) Rename(oldKey, newKey string) error {
+	return db.rename(oldKey, newKey, true)
+}
+
+// RenameNX is like Rename but returns ErrKeyExists instead of overwriting an existing newKey.
+func (db *DB[T]) RenameNX(oldKey, newKey string) error {
+	return db.rename(oldKey, newKey, false)
+}
+
+// rename implements Rename and RenameNX.
+func (db *DB[T]) rename(oldKey, newKey string, overwrite bool) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	db.purgeExpired(time.Now())
+	value, exists := db.data[oldKey]
+	if !exists {
+		return ErrKeyNotFound
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	if _, taken := db.data[newKey]; taken && !overwrite {
+		return ErrKeyExists
+	}
+
+	if err := db.validateValue(newKey, value); err != nil {
+		return err
+	}
+	if err := db.checkChange(map[string]T{newKey: value}, oldKey); err != nil {
+		return err
+	}
+
+	saved := db.saveKeys([]string{oldKey, newKey})
+	db.data[newKey] = value
+	delete(db.expires, newKey)
+	if expiry, ok := db.expires[oldKey]; ok {
+		db.expires[newKey] = expiry
+	}
+	delete(db.data, oldKey)
+	delete(db.expires, oldKey)
+
+	events := []Event[T]{
+		{Key: oldKey, Op: OpDelete},
+		{Key: newKey, Value: value, Op: OpSet},
+	}
+	if err := db.commit(events, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
+}
+
+// WithEquality sets how values are compared wherever the database decides
+// whether two values are equal: CompareAndSwap, KeysWithValue with a nil eq,
+// the Changeset returned by DryRun, and which keys changed by a batch write,
+// transaction or reload are announced to hooks and subscribers. A value it
+// considers equal is still stored, persisted, indexed and recorded for
+// Changes. The default is reflect.DeepEqual, which can be slow and treats
+// values such as times in different locations as different. fn is called
+// under the lock, so it must not call back into the database.
+// T must match the value type of the database being opened.
+func WithEquality[T any](fn func(a, b T) bool) Option {
+	return func(c *config) {
+		c.equality = fn
+	}
+}
+
+// equal reports whether two values are considered equal.
+func (db *DB[T]) equal(a, b T) bool {
+	if db.equality != nil {
+		return db.equality(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
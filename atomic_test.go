@@ -0,0 +1,147 @@
+package smalldb_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	alice := User{Name: "Alice", Age: 30}
+	_ = db.Set("user:1", alice)
+
+	swapped, err := db.CompareAndSwap("user:1", User{Name: "Alice", Age: 99}, User{Name: "Alice", Age: 31})
+	if err != nil || swapped {
+		t.Fatalf("Expected mismatched swap to fail, got %v, %v", swapped, err)
+	}
+
+	swapped, err = db.CompareAndSwap("user:1", alice, User{Name: "Alice", Age: 31})
+	if err != nil || !swapped {
+		t.Fatalf("Expected swap to succeed, got %v, %v", swapped, err)
+	}
+
+	if user, _ := db.Get("user:1"); user.Age != 31 {
+		t.Fatalf("Expected swapped value, got %v", user)
+	}
+
+	if swapped, _ := db.CompareAndSwap("user:missing", User{}, alice); swapped {
+		t.Fatalf("Expected swap on missing key to fail")
+	}
+}
+
+func TestSetIfAbsentAndGetOrSet(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+
+	inserted, err := db.SetIfAbsent("config", User{Name: "default"})
+	if err != nil || !inserted {
+		t.Fatalf("Expected first SetIfAbsent to insert, got %v, %v", inserted, err)
+	}
+
+	inserted, _ = db.SetIfAbsent("config", User{Name: "other"})
+	if inserted {
+		t.Fatalf("Expected second SetIfAbsent not to insert")
+	}
+
+	value, loaded, err := db.GetOrSet("config", User{Name: "ignored"})
+	if err != nil || !loaded || value.Name != "default" {
+		t.Fatalf("Expected existing value, got %v, %v, %v", value, loaded, err)
+	}
+
+	value, loaded, _ = db.GetOrSet("fresh", User{Name: "new"})
+	if loaded || value.Name != "new" {
+		t.Fatalf("Expected GetOrSet to store new value, got %v, %v", value, loaded)
+	}
+}
+
+func TestRename(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+
+	if err := db.Rename("user:1", "user:10"); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	if db.Has("user:1") {
+		t.Fatalf("Expected old key to be gone")
+	}
+	if user, _ := db.Get("user:10"); user.Name != "Alice" {
+		t.Fatalf("Expected Alice under new key, got %v", user)
+	}
+
+	if err := db.Rename("user:missing", "user:3"); !errors.Is(err, smalldb.ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	if err := db.RenameNX("user:10", "user:2"); !errors.Is(err, smalldb.ErrKeyExists) {
+		t.Fatalf("Expected ErrKeyExists, got %v", err)
+	}
+
+	if err := db.Rename("user:10", "user:2"); err != nil {
+		t.Fatalf("Expected Rename to overwrite, got %v", err)
+	}
+	if user, _ := db.Get("user:2"); user.Name != "Alice" || db.Len() != 1 {
+		t.Fatalf("Expected Alice to overwrite Bob, got %v", db.GetAll())
+	}
+}
+
+func TestWithEquality(t *testing.T) {
+	sameName := func(a, b User) bool { return strings.EqualFold(a.Name, b.Name) }
+	db := smalldb.OpenMemory[User](smalldb.WithEquality(sameName))
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+
+	swapped, err := db.CompareAndSwap("user:1", User{Name: "alice"}, User{Name: "Alice", Age: 31})
+	if err != nil || !swapped {
+		t.Fatalf("Expected the swap to use the configured equality, got %v, %v", swapped, err)
+	}
+
+	if keys := db.KeysWithValue(User{Name: "BOB"}, nil); len(keys) != 1 || keys[0] != "user:2" {
+		t.Fatalf("Expected [user:2], got %v", keys)
+	}
+
+	changes, err := db.DryRun(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", User{Name: "bob", Age: 99})
+		return nil
+	})
+	if err != nil || !changes.IsEmpty() {
+		t.Fatalf("Expected no changes under the configured equality, got %+v, %v", changes, err)
+	}
+}
+
+func TestWithEqualityStillStoresWrites(t *testing.T) {
+	type item struct {
+		ID   int
+		Note string
+	}
+	sameID := func(a, b item) bool { return a.ID == b.ID }
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[item](file, smalldb.WithWAL(), smalldb.WithEquality(sameID))
+	db.Index("note", func(v item) string { return v.Note })
+	events, unsubscribe := db.Subscribe()
+	defer unsubscribe()
+
+	_ = db.Set("a", item{ID: 1, Note: "old"})
+	<-events
+	_ = db.SetMany(map[string]item{"a": {ID: 1, Note: "new"}})
+
+	if got := db.ByIndex("note", "new"); len(got) != 1 {
+		t.Fatalf("Expected the index to see the new note, got %v", got)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("Expected no event for a value considered equal, got %v", e)
+	default:
+	}
+	_ = db.Close()
+
+	reopened, _ := smalldb.Open[item](file, smalldb.WithWAL())
+	defer reopened.Close()
+	if got, _ := reopened.Get("a"); got.Note != "new" {
+		t.Fatalf("Expected the write to be persisted, got %+v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package smalldb
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// autoSnapshotPrefix starts the name of every file written by WithAutoSnapshot.
+const autoSnapshotPrefix = "snapshot-"
+
+// autoSnapshotTime formats snapshot timestamps so names sort chronologically.
+const autoSnapshotTime = "20060102T150405.000000000Z"
+
+// WithAutoSnapshot writes a consistent snapshot of the database to dir every
+// interval, independently of normal persistence. Snapshots are compressed,
+// encrypted and checksummed like the database file, so one can be opened with
+// the same options, and are synced as WithSync requires. Files are named by
+// the UTC time they were taken, such as
+// snapshot-20240102T150405.000000000Z.json, and only the keep most recent are
+// kept; keep of zero or less keeps every snapshot. Failed snapshots are
+// reported through WithLogger and retried on the next tick. Close stops the
+// snapshots without taking a final one. In-memory databases are snapshotted
+// too.
+func WithAutoSnapshot(interval time.Duration, dir string, keep int) Option {
+	return func(c *config) {
+		c.snapshotInterval = interval
+		c.snapshotDir = dir
+		c.snapshotKeep = keep
+	}
+}
+
+// snapshotLoop takes an automatic snapshot once per interval until the database is closed.
+func (db *DB[T]) snapshotLoop(interval time.Duration) {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			if err := db.autoSnapshot(time.Now()); err != nil && db.cfg.logger != nil {
+				db.cfg.logger.Warn("smalldb: automatic snapshot failed", "dir", db.cfg.snapshotDir, "error", err)
+			}
+		}
+	}
+}
+
+// autoSnapshot writes a snapshot taken at now to the snapshot directory and
+// removes snapshots beyond the number to keep.
+func (db *DB[T]) autoSnapshot(now time.Time) error {
+	dir := db.cfg.snapshotDir
+	if err := os.MkdirAll(dir, db.cfg.dirMode); err != nil {
+		return err
+	}
+
+	name := autoSnapshotPrefix + now.UTC().Format(autoSnapshotTime) + filepath.Ext(db.filepath)
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, db.cfg.fileMode)
+	if err != nil {
+		return err
+	}
+	if err := db.packedSnapshot(file); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := syncFile(file, db.cfg.sync); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := syncDirOf(path, db.cfg.sync); err != nil {
+		return err
+	}
+
+	return pruneSnapshots(dir, db.cfg.snapshotKeep)
+}
+
+// packedSnapshot writes a consistent copy of the database to w exactly as the
+// database file is written, applying the configured compression, encryption
+// and checksum.
+func (db *DB[T]) packedSnapshot(w io.Writer) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	packed := newPackWriter(w, &db.cfg)
+	if err := encodeData(packed, &envelope[T]{Data: db.data, Expires: db.expires}, &db.cfg); err != nil {
+		return err
+	}
+	return packed.Close()
+}
+
+// pruneSnapshots removes all but the keep most recent automatic snapshots in dir.
+func pruneSnapshots(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, autoSnapshotPrefix) && !strings.HasSuffix(name, ".tmp") {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= keep {
+		return nil
+	}
+
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
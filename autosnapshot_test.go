@@ -0,0 +1,90 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestAutoSnapshotKeepsMostRecent(t *testing.T) {
+	dir := t.TempDir()
+	snapshots := filepath.Join(dir, "snapshots")
+
+	db, _ := smalldb.Open[User](filepath.Join(dir, "db.json"), smalldb.WithAutoSnapshot(5*time.Millisecond, snapshots, 2))
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, _ := os.ReadDir(snapshots)
+		if len(entries) == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+	_ = db.Close()
+
+	entries, err := os.ReadDir(snapshots)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot directory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 snapshots to be kept, got %d", len(entries))
+	}
+	name := entries[len(entries)-1].Name()
+	if !strings.HasPrefix(name, "snapshot-") || !strings.HasSuffix(name, ".json") {
+		t.Fatalf("Unexpected snapshot name %q", name)
+	}
+
+	f, _ := os.Open(filepath.Join(snapshots, name))
+	defer f.Close()
+	restored := smalldb.OpenMemory[User]()
+	if err := restored.Restore(f); err != nil {
+		t.Fatalf("Failed to restore snapshot: %v", err)
+	}
+	if user, ok := restored.Get("user:1"); !ok || user.Name != "Alice" {
+		t.Fatalf("Expected Alice in the snapshot, got %v (found: %v)", user, ok)
+	}
+}
+
+func TestAutoSnapshotIsEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	snapshots := filepath.Join(dir, "snapshots")
+	key := []byte("0123456789abcdef")
+
+	db, _ := smalldb.Open[User](filepath.Join(dir, "db.json"),
+		smalldb.WithEncryption(key), smalldb.WithChecksum(),
+		smalldb.WithAutoSnapshot(5*time.Millisecond, snapshots, 1))
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	deadline := time.Now().Add(5 * time.Second)
+	var entries []os.DirEntry
+	for len(entries) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		entries, _ = os.ReadDir(snapshots)
+	}
+	_ = db.Close()
+	if len(entries) == 0 {
+		t.Fatalf("Expected a snapshot to be written")
+	}
+
+	entries, _ = os.ReadDir(snapshots)
+	path := filepath.Join(snapshots, entries[0].Name())
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "Alice") {
+		t.Fatalf("Expected the snapshot to be encrypted, got %q", raw)
+	}
+
+	snapshot, err := smalldb.Open[User](path, smalldb.WithEncryption(key), smalldb.WithChecksum(), smalldb.WithReadOnly())
+	if err != nil {
+		t.Fatalf("Failed to open the snapshot with the database's options: %v", err)
+	}
+	defer snapshot.Close()
+	if user, _ := snapshot.Get("user:1"); user.Name != "Alice" {
+		t.Fatalf("Expected Alice in the snapshot, got %+v", user)
+	}
+}
@@ -0,0 +1,140 @@
+package smalldb
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// Storage holds the encoded contents of a database somewhere other than the
+// local filesystem, such as in memory for tests or in a blob store.
+// Read must return an error matching fs.ErrNotExist when nothing has been
+// written yet. Write must replace the stored contents atomically.
+type Storage interface {
+	Read() ([]byte, error)
+	Write(data []byte) error
+}
+
+// WithStorage reads and writes the database through st instead of the file at
+// the path given to Open, which then only names the database. Nothing is
+// created on disk and no file lock is taken, so WithBackups, WithWAL and
+// WithAutoReload, which need a local file, cannot be used with it.
+func WithStorage(st Storage) Option {
+	return func(c *config) {
+		c.storage = st
+	}
+}
+
+// OpenFS opens the database stored in the file name of fsys, such as an
+// embed.FS holding seed data built into the binary. The database is always
+// read-only, as WithReadOnly, and a missing file opens as an empty database.
+func OpenFS[T any](fsys fs.FS, name string, opts ...Option) (*DB[T], error) {
+	opts = append(opts, WithStorage(fsStorage{fsys: fsys, name: name}), WithReadOnly())
+	return Open[T](name, opts...)
+}
+
+// fsStorage reads a database from a file in an fs.FS.
+type fsStorage struct {
+	fsys fs.FS
+	name string
+}
+
+// Read returns the contents of the file.
+func (s fsStorage) Read() ([]byte, error) {
+	return fs.ReadFile(s.fsys, s.name)
+}
+
+// Write always fails, as an fs.FS cannot be written.
+func (s fsStorage) Write([]byte) error {
+	return ErrReadOnly
+}
+
+// storageFor returns the storage configured with WithStorage, or the file at path.
+func storageFor(path string, cfg *config) Storage {
+	if cfg.storage != nil {
+		return cfg.storage
+	}
+	return fileStorage{
+		path:    path,
+		tmpDir:  cfg.tempDir,
+		mode:    cfg.fileMode,
+		backups: cfg.backups,
+		sync:    cfg.sync,
+		logger:  cfg.logger,
+	}
+}
+
+// streamingStorage is implemented by storage that can be written incrementally.
+type streamingStorage interface {
+	writeWith(fn func(w io.Writer) error) error
+}
+
+// fileStorage is the default storage: a file on the local filesystem.
+type fileStorage struct {
+	path    string
+	tmpDir  string
+	mode    os.FileMode
+	backups int
+	sync    SyncMode
+	logger  *slog.Logger
+}
+
+// Read returns the contents of the file.
+func (s fileStorage) Read() ([]byte, error) {
+	return os.ReadFile(s.path)
+}
+
+// Write replaces the contents of the file with data.
+func (s fileStorage) Write(data []byte) error {
+	return s.writeWith(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeWith replaces the file with whatever fn writes.
+// The data is written to a temporary file, next to the target unless
+// WithTempDir says otherwise, which is synced as the SyncMode requires and then
+// renamed over the target, so a crash mid-write never leaves a truncated file.
+func (s fileStorage) writeWith(fn func(w io.Writer) error) error {
+	file, err := s.createTemp()
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+
+	buffered := bufio.NewWriter(file)
+	if err := fn(buffered); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := buffered.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := syncFile(file, s.sync); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if s.backups > 0 {
+		if err := rotateBackups(s.path, s.backups, s.mode); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+
+	return s.renameInto(tmp)
+}
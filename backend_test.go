@@ -0,0 +1,92 @@
+package smalldb_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+// memStorage keeps the encoded database in memory.
+type memStorage struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (m *memStorage) Read() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		return nil, fs.ErrNotExist
+	}
+	return append([]byte(nil), m.data...), nil
+}
+
+func (m *memStorage) Write(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = append([]byte(nil), data...)
+	return nil
+}
+
+func TestWithStorage(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "missing", "db.json")
+	st := &memStorage{}
+
+	db, err := smalldb.Open[User](file, smalldb.WithStorage(st))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.Set("user:1", User{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	db.Close()
+
+	if len(st.data) == 0 {
+		t.Fatal("Expected the data to be written to the storage")
+	}
+	if _, err := os.Stat(filepath.Dir(file)); !os.IsNotExist(err) {
+		t.Fatalf("Expected nothing to be created on disk, got %v", err)
+	}
+
+	db, err = smalldb.Open[User](file, smalldb.WithStorage(st))
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	if user, ok := db.Get("user:1"); !ok || user.Name != "Alice" {
+		t.Fatalf("Expected Alice from the storage, got %v (found: %v)", user, ok)
+	}
+}
+
+func TestWithStorageRejectsBackups(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	if _, err := smalldb.Open[User](file, smalldb.WithStorage(&memStorage{}), smalldb.WithBackups(2)); err == nil {
+		t.Fatal("Expected WithStorage with WithBackups to be rejected")
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"seed/db.json": {Data: []byte(`{"user:1": {"Name": "Alice", "Age": 30}}`)},
+	}
+
+	db, err := smalldb.OpenFS[User](fsys, "seed/db.json")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if user, ok := db.Get("user:1"); !ok || user.Name != "Alice" {
+		t.Fatalf("Expected Alice, got %v (found: %v)", user, ok)
+	}
+	if err := db.Set("user:2", User{Name: "Bob"}); !errors.Is(err, smalldb.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+}
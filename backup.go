@@ -0,0 +1,110 @@
+package smalldb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// WithBackups keeps up to count previous versions of the database file.
+// Before each write the current file is copied to <path>.bak.1, shifting older
+// backups up by one and dropping the oldest, so <path>.bak.1 is always the
+// most recent. Use RestoreBackup to go back to one of them.
+func WithBackups(count int) Option {
+	return func(c *config) {
+		c.backups = count
+	}
+}
+
+// RestoreBackup replaces the database contents with those of backup n, where
+// 1 is the most recent, and persists the result. The file being replaced is
+// itself rotated into the backups, so a restore can be undone.
+// Subscribers receive events for every key the restore changed.
+func (db *DB[T]) RestoreBackup(n int) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if db.cfg.memory {
+		return errors.New("smalldb: in-memory databases have no backups")
+	}
+	if n < 1 || n > db.cfg.backups {
+		return fmt.Errorf("smalldb: backup %d out of range, %d backups are kept", n, db.cfg.backups)
+	}
+
+	path := backupPath(db.filepath, n)
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	env, err := readData[T](path, &db.cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := db.checkInvariants(env.Data); err != nil {
+		return err
+	}
+
+	oldData, oldExpires := db.data, db.expires
+	db.data = env.Data
+	db.expires = env.Expires
+	db.purgeExpired(time.Now())
+
+	events := db.diffEvents(oldData, db.data, oldExpires, db.expires, allKeys(oldData, db.data))
+	if err := db.commit(events, oldData); err != nil {
+		db.restoreSwap(oldData, oldExpires)
+		return err
+	}
+
+	if len(db.expires) > 0 {
+		db.startSweeper()
+	}
+	return nil
+}
+
+// backupPath returns the path of backup n of the file at fp.
+func backupPath(fp string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", fp, n)
+}
+
+// rotateBackups shifts the existing backups of fp up by one, dropping any
+// beyond count, and copies fp to backup 1. A missing fp is not an error.
+func rotateBackups(fp string, count int, mode os.FileMode) error {
+	if _, err := os.Stat(fp); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.Remove(backupPath(fp, count)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := count - 1; i >= 1; i-- {
+		err := os.Rename(backupPath(fp, i), backupPath(fp, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return copyFile(fp, backupPath(fp, 1), mode)
+}
+
+// copyFile copies the contents of src to dst, replacing dst.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,44 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestBackupRotation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[int](file, smalldb.WithBackups(2))
+	defer db.Close()
+	for i := 1; i <= 4; i++ {
+		if err := db.Set("n", i); err != nil {
+			t.Fatalf("Failed to set: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(file + ".bak.3"); !os.IsNotExist(err) {
+		t.Fatalf("Expected only 2 backups to be kept, got %v", err)
+	}
+
+	if err := db.RestoreBackup(2); err != nil {
+		t.Fatalf("Failed to restore backup: %v", err)
+	}
+	if value, _ := db.Get("n"); value != 2 {
+		t.Fatalf("Expected 2 after restoring backup 2, got %d", value)
+	}
+
+	// The restore rotated the replaced state into the most recent backup.
+	if err := db.RestoreBackup(1); err != nil {
+		t.Fatalf("Failed to undo restore: %v", err)
+	}
+	if value, _ := db.Get("n"); value != 4 {
+		t.Fatalf("Expected 4 after undoing the restore, got %d", value)
+	}
+
+	if err := db.RestoreBackup(3); err == nil {
+		t.Fatalf("Expected an error for an out of range backup")
+	}
+}
@@ -0,0 +1,298 @@
+package smalldb
+
+import "time"
+
+// SetMany sets all of the given key-value pairs under a single lock and persists once.
+// Either all values are stored or, if persisting fails, none are.
+func (db *DB[T]) SetMany(items map[string]T) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	return db.setMany(items)
+}
+
+// setMany stores items, clearing their TTLs, and persists once, restoring the
+// previous state if persisting fails.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) setMany(items map[string]T) error {
+	if err := db.validateKeys(items, allKeys(items)); err != nil {
+		return err
+	}
+	return db.storeMany(items)
+}
+
+// SetManyLenient is like SetMany but stores only the items that pass the
+// validators set with WithValidator and WithKeyValidator, skipping the rest,
+// and persists once. It returns how many items were stored and, for each
+// rejected key, the error it failed validation with. err reports why the
+// accepted items could not be stored, such as ErrReadOnly, a failed invariant
+// or a persist error, in which case none of them are.
+func (db *DB[T]) SetManyLenient(items map[string]T) (accepted int, rejected map[string]error, err error) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return 0, nil, err
+	}
+
+	valid := make(map[string]T, len(items))
+	for k, v := range items {
+		if err := db.validateValue(k, v); err != nil {
+			if rejected == nil {
+				rejected = make(map[string]error)
+			}
+			rejected[k] = err
+			continue
+		}
+		valid[k] = v
+	}
+	if len(valid) == 0 {
+		return 0, rejected, nil
+	}
+
+	if err := db.storeMany(valid); err != nil {
+		return 0, rejected, err
+	}
+	return len(valid), rejected, nil
+}
+
+// storeMany stores items that have already been validated, clearing their
+// TTLs, and persists once, restoring the previous state if persisting fails.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) storeMany(items map[string]T) error {
+	if err := db.checkChange(items); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	saved := db.saveKeys(keys)
+
+	events := db.diffEvents(saved.values, items, saved.expires, nil, allKeys(items))
+	for k, v := range items {
+		db.data[k] = v
+		delete(db.expires, k)
+	}
+
+	if err := db.commit(events, saved.values); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
+}
+
+// DeleteMany removes all of the given keys under a single lock and persists once.
+// Either all keys are removed or, if persisting fails, none are.
+func (db *DB[T]) DeleteMany(keys []string) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	if err := db.checkChange(nil, keys...); err != nil {
+		return err
+	}
+
+	saved := db.saveKeys(keys)
+
+	events := db.diffEvents(saved.values, nil, saved.expires, nil, allKeys(saved.values))
+	for _, k := range keys {
+		delete(db.data, k)
+		delete(db.expires, k)
+	}
+
+	if err := db.commit(events, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
+}
+
+// DeleteWhere removes every entry for which pred returns true under a single
+// lock, persists once and returns the number of entries removed. pred is called
+// under the write lock, so it must not call back into the database. If nothing
+// matches the file is not rewritten; if persisting fails, nothing is removed.
+func (db *DB[T]) DeleteWhere(pred func(key string, value T) bool) (int, error) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return 0, err
+	}
+
+	db.purgeExpired(time.Now())
+
+	// Collect the matches first so the map is not modified while ranging over it.
+	var keys []string
+	for k, v := range db.data {
+		if pred(k, v) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if err := db.checkChange(nil, keys...); err != nil {
+		return 0, err
+	}
+
+	saved := db.saveKeys(keys)
+	events := db.diffEvents(saved.values, nil, saved.expires, nil, allKeys(saved.values))
+	for _, k := range keys {
+		delete(db.data, k)
+		delete(db.expires, k)
+	}
+
+	if err := db.commit(events, nil); err != nil {
+		saved.restore()
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// MapValues replaces every value with the result of fn under a single lock and
+// persists once, keeping any TTLs. If fn returns an error for any entry,
+// nothing is changed and the error is returned. fn is called under the write
+// lock, so it must not call back into the database.
+func (db *DB[T]) MapValues(fn func(key string, value T) (T, error)) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	db.purgeExpired(time.Now())
+	mapped := make(map[string]T, len(db.data))
+	for k, v := range db.data {
+		value, err := fn(k, v)
+		if err != nil {
+			return err
+		}
+		mapped[k] = value
+	}
+	if err := db.validateKeys(mapped, allKeys(mapped)); err != nil {
+		return err
+	}
+	if err := db.checkInvariants(mapped); err != nil {
+		return err
+	}
+
+	old := db.data
+	db.data = mapped
+	if err := db.commit(db.diffEvents(old, mapped, db.expires, db.expires, allKeys(mapped)), old); err != nil {
+		db.restoreSwap(old, db.expires)
+		return err
+	}
+	return nil
+}
+
+// Prune calls fn for every entry under a single lock, removing the entry if
+// fn returns false and otherwise storing the value fn returns, keeping any TTL.
+// It persists once and returns the number of entries removed. If nothing
+// changes the file is not rewritten; if persisting fails, nothing is changed.
+// fn is called under the write lock, so it must not call back into the database.
+func (db *DB[T]) Prune(fn func(key string, value T) (newValue T, keep bool)) (int, error) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return 0, err
+	}
+
+	db.purgeExpired(time.Now())
+	pruned := make(map[string]T, len(db.data))
+	expires := make(map[string]time.Time, len(db.expires))
+	for k, v := range db.data {
+		value, keep := fn(k, v)
+		if !keep {
+			continue
+		}
+		pruned[k] = value
+		if expiry, ok := db.expires[k]; ok {
+			expires[k] = expiry
+		}
+	}
+
+	events := db.diffEvents(db.data, pruned, db.expires, expires, allKeys(db.data))
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if err := db.validateKeys(pruned, allKeys(pruned)); err != nil {
+		return 0, err
+	}
+	if err := db.checkInvariants(pruned); err != nil {
+		return 0, err
+	}
+
+	oldData, oldExpires := db.data, db.expires
+	db.data, db.expires = pruned, expires
+	if err := db.commit(events, oldData); err != nil {
+		db.restoreSwap(oldData, oldExpires)
+		return 0, err
+	}
+	return len(oldData) - len(pruned), nil
+}
+
+// savedKeys records the state of a set of keys so changes to them can be undone.
+type savedKeys[T any] struct {
+	db      *DB[T]
+	values  map[string]T
+	expires map[string]time.Time
+	missing []string
+}
+
+// saveKeys captures the current value and expiry of each key.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) saveKeys(keys []string) *savedKeys[T] {
+	saved := &savedKeys[T]{
+		db:      db,
+		values:  make(map[string]T, len(keys)),
+		expires: make(map[string]time.Time),
+	}
+	for _, k := range keys {
+		value, exists := db.data[k]
+		if !exists {
+			saved.missing = append(saved.missing, k)
+			continue
+		}
+		saved.values[k] = value
+		if expiry, ok := db.expires[k]; ok {
+			saved.expires[k] = expiry
+		}
+	}
+	return saved
+}
+
+// restore puts every saved key back to the state it had when it was saved,
+// along with its entries in the indexes. Restoring nil does nothing.
+// The caller must hold db.mu for writing.
+func (s *savedKeys[T]) restore() {
+	if s == nil {
+		return
+	}
+	for _, k := range s.missing {
+		delete(s.db.data, k)
+		delete(s.db.expires, k)
+	}
+	for k, v := range s.values {
+		s.db.data[k] = v
+		if expiry, ok := s.expires[k]; ok {
+			s.db.expires[k] = expiry
+		} else {
+			delete(s.db.expires, k)
+		}
+	}
+	s.db.reindex(s.missing...)
+	for k := range s.values {
+		s.db.reindex(k)
+	}
+}
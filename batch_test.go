@@ -0,0 +1,167 @@
+package smalldb_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestSetManyAndDeleteMany(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+	err := db.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+		"user:3": {Name: "Charlie", Age: 28},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set many: %v", err)
+	}
+
+	if err := db.DeleteMany([]string{"user:1", "user:3", "user:missing"}); err != nil {
+		t.Fatalf("Failed to delete many: %v", err)
+	}
+
+	_ = db.Close()
+	reopened, _ := smalldb.Open[User](file)
+	defer reopened.Close()
+	if reopened.Len() != 1 || !reopened.Has("user:2") {
+		t.Fatalf("Expected only user:2 to remain, got %v", reopened.GetAll())
+	}
+}
+
+func TestSetManyRollsBackOnPersistError(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[flaky](file)
+	defer db.Close()
+	_ = db.Set("a", flaky{Value: "original"})
+
+	err := db.SetMany(map[string]flaky{
+		"a": {Value: "changed"},
+		"b": {Value: "bad", Fail: true},
+	})
+	if err == nil {
+		t.Fatalf("Expected SetMany to fail")
+	}
+
+	if value, _ := db.Get("a"); value.Value != "original" {
+		t.Fatalf("Expected a to be restored, got %v", value)
+	}
+	if db.Has("b") {
+		t.Fatalf("Expected b not to be stored")
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 17},
+		"user:3": {Name: "Charlie", Age: 15},
+	})
+
+	n, err := db.DeleteWhere(func(_ string, u User) bool { return u.Age < 18 })
+	if err != nil {
+		t.Fatalf("Failed to delete where: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("Expected 2 deletions, got %d", n)
+	}
+	if db.Len() != 1 || !db.Has("user:1") {
+		t.Fatalf("Expected only user:1 to remain, got %v", db.GetAll())
+	}
+
+	if n, _ := db.DeleteWhere(func(string, User) bool { return false }); n != 0 {
+		t.Fatalf("Expected 0 deletions, got %d", n)
+	}
+}
+
+func TestMapValues(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "alice", Age: 30},
+		"user:2": {Name: "bob", Age: 25},
+	})
+
+	err := db.MapValues(func(_ string, u User) (User, error) {
+		u.Name = strings.ToUpper(u.Name)
+		return u, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to map values: %v", err)
+	}
+	if value, _ := db.Get("user:2"); value.Name != "BOB" {
+		t.Fatalf("Expected BOB, got %v", value)
+	}
+
+	errAbort := errors.New("abort")
+	err = db.MapValues(func(key string, u User) (User, error) {
+		if key == "user:2" {
+			return u, errAbort
+		}
+		u.Age = 0
+		return u, nil
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected abort error, got %v", err)
+	}
+	if value, _ := db.Get("user:1"); value.Age != 30 {
+		t.Fatalf("Expected no change after an aborted MapValues, got %v", value)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "alice", Age: 30},
+		"user:2": {Name: "bob", Age: 15},
+		"user:3": {Name: "charlie", Age: 12},
+	})
+
+	removed, err := db.Prune(func(_ string, u User) (User, bool) {
+		u.Name = strings.ToUpper(u.Name)
+		return u, u.Age >= 18
+	})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("Expected 2 entries removed, got %d", removed)
+	}
+	if all := db.GetAll(); len(all) != 1 || all["user:1"].Name != "ALICE" {
+		t.Fatalf("Expected only ALICE to remain, got %v", all)
+	}
+}
+
+func TestSetManyLenient(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithValidator(validUser))
+	defer db.Close()
+
+	accepted, rejected, err := db.SetManyLenient(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: -1},
+		"user:3": {Name: "Charlie", Age: 28},
+	})
+	if err != nil {
+		t.Fatalf("SetManyLenient failed: %v", err)
+	}
+	if accepted != 2 || len(rejected) != 1 || !errors.Is(rejected["user:2"], errInvalidAge) {
+		t.Fatalf("Expected 2 accepted and user:2 rejected, got %d and %v", accepted, rejected)
+	}
+
+	reopened, _ := smalldb.Open[User](file, smalldb.WithoutLocking())
+	defer reopened.Close()
+	if !reopened.Has("user:1") || !reopened.Has("user:3") || reopened.Has("user:2") {
+		t.Fatalf("Expected only the valid items to be stored, got %v", reopened.GetAll())
+	}
+}
@@ -0,0 +1,81 @@
+package smalldb
+
+// WithChangeLog keeps the last n changes in memory so Changes can return
+// them. Older changes are discarded as new ones arrive, and a reader whose
+// cursor falls behind them gets ErrTooFarBehind.
+func WithChangeLog(n int) Option {
+	return func(c *config) {
+		c.changeLog = n
+	}
+}
+
+// changeLog numbers every change to the database and keeps the most recent
+// ones in a ring buffer. Version v, counting from 1, is stored at
+// events[(v-1) % len(events)].
+type changeLog[T any] struct {
+	version uint64     // Version of the newest change.
+	count   int        // Number of changes held in events.
+	events  []Event[T] // Ring buffer; nil without WithChangeLog.
+}
+
+// newChangeLog returns a change log holding up to n changes.
+func newChangeLog[T any](n int) changeLog[T] {
+	if n <= 0 {
+		return changeLog[T]{}
+	}
+	return changeLog[T]{events: make([]Event[T], n)}
+}
+
+// enabled reports whether changes are kept, as set by WithChangeLog.
+func (l *changeLog[T]) enabled() bool {
+	return len(l.events) > 0
+}
+
+// record assigns the next versions to events and keeps them.
+func (l *changeLog[T]) record(events []Event[T]) {
+	for _, e := range events {
+		l.version++
+		if !l.enabled() {
+			continue
+		}
+		l.events[(l.version-1)%uint64(len(l.events))] = e
+		l.count = min(l.count+1, len(l.events))
+	}
+}
+
+// since returns the changes made after version since, oldest first.
+func (l *changeLog[T]) since(since uint64) ([]Event[T], error) {
+	if !l.enabled() || since > l.version || since < l.version-uint64(l.count) {
+		return nil, ErrTooFarBehind
+	}
+
+	changes := make([]Event[T], 0, l.version-since)
+	for v := since + 1; v <= l.version; v++ {
+		changes = append(changes, l.events[(v-1)%uint64(len(l.events))])
+	}
+	return changes, nil
+}
+
+// Changes returns every change made after version since, oldest first, and
+// the current version to pass as since next time. Each change to a key is one
+// Event and advances the version by one, starting from zero when the database
+// is opened, so versions do not carry over to another process or a restart.
+// Only the last n changes are kept (see WithChangeLog); if some changes after
+// since have been discarded, or since is newer than the current version,
+// Changes returns ErrTooFarBehind and the current version. Without
+// WithChangeLog nothing is kept and Changes always returns ErrTooFarBehind.
+// The caller should then copy the whole database, for example with GetAll, and
+// continue from the version it was given: replaying a change the copy already
+// reflects is harmless, since every event sets or deletes a whole key. Changes made by
+// Reload are included; as with Subscribe, entries removed on expiry are not.
+func (db *DB[T]) Changes(since uint64) ([]Event[T], uint64, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil, 0, ErrClosed
+	}
+
+	changes, err := db.changes.since(since)
+	return changes, db.changes.version, err
+}
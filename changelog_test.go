@@ -0,0 +1,56 @@
+package smalldb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestChanges(t *testing.T) {
+	db := smalldb.OpenMemory[int](smalldb.WithChangeLog(3))
+
+	_ = db.Set("a", 1)
+	_ = db.Set("b", 2)
+	_ = db.Delete("a")
+
+	changes, version, err := db.Changes(0)
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+	want := []smalldb.Event[int]{
+		{Key: "a", Value: 1, Op: smalldb.OpSet},
+		{Key: "b", Value: 2, Op: smalldb.OpSet},
+		{Key: "a", Op: smalldb.OpDelete},
+	}
+	if version != 3 || !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Expected %v at version 3, got %v at version %d", want, changes, version)
+	}
+
+	if changes, _, err := db.Changes(version); err != nil || len(changes) != 0 {
+		t.Fatalf("Expected no changes since the current version, got %v, %v", changes, err)
+	}
+
+	_ = db.Set("c", 3)
+	if _, version, err := db.Changes(0); !errors.Is(err, smalldb.ErrTooFarBehind) || version != 4 {
+		t.Fatalf("Expected ErrTooFarBehind at version 4, got %v at version %d", err, version)
+	}
+	changes, _, err = db.Changes(2)
+	if err != nil || len(changes) != 2 || changes[1].Key != "c" {
+		t.Fatalf("Expected the last two changes, got %v, %v", changes, err)
+	}
+
+	if _, _, err := db.Changes(10); !errors.Is(err, smalldb.ErrTooFarBehind) {
+		t.Fatalf("Expected ErrTooFarBehind for a version from the future, got %v", err)
+	}
+}
+
+func TestChangesWithoutChangeLog(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.Set("a", 1)
+
+	if _, version, err := db.Changes(0); !errors.Is(err, smalldb.ErrTooFarBehind) || version != 1 {
+		t.Fatalf("Expected ErrTooFarBehind at version 1, got %v at version %d", err, version)
+	}
+}
@@ -0,0 +1,54 @@
+package smalldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// The checksum header is laid out as:
+//
+//	magic (4 bytes) | version (1 byte) | CRC-32 IEEE of payload (4 bytes, big-endian)
+//
+// followed by the payload. New header versions must keep the magic and version
+// fields in place so older files remain readable.
+const (
+	checksumVersion    = 1
+	checksumHeaderSize = 9
+)
+
+// checksumMagic identifies data carrying a checksum header.
+var checksumMagic = []byte("SDBC")
+
+// hasChecksum reports whether data starts with a checksum header.
+func hasChecksum(data []byte) bool {
+	return bytes.HasPrefix(data, checksumMagic)
+}
+
+// addChecksum prefixes payload with a checksum header.
+func addChecksum(payload []byte) []byte {
+	out := make([]byte, checksumHeaderSize, checksumHeaderSize+len(payload))
+	copy(out, checksumMagic)
+	out[4] = checksumVersion
+	binary.BigEndian.PutUint32(out[5:], crc32.ChecksumIEEE(payload))
+	return append(out, payload...)
+}
+
+// verifyChecksum checks the header written by addChecksum and returns the payload.
+func verifyChecksum(data []byte) ([]byte, error) {
+	if len(data) < checksumHeaderSize {
+		return nil, fmt.Errorf("%w: checksum header truncated", ErrCorrupted)
+	}
+
+	if version := data[4]; version != checksumVersion {
+		return nil, fmt.Errorf("%w: unsupported checksum header version %d", ErrCorrupted, version)
+	}
+
+	payload := data[checksumHeaderSize:]
+	want := binary.BigEndian.Uint32(data[5:checksumHeaderSize])
+	if got := crc32.ChecksumIEEE(payload); got != want {
+		return nil, fmt.Errorf("%w: checksum mismatch (want %08x, got %08x)", ErrCorrupted, want, got)
+	}
+	return payload, nil
+}
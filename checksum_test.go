@@ -0,0 +1,40 @@
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestChecksum(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithChecksum())
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Close()
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithChecksum())
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if !reopened.Has("user:1") {
+		t.Fatalf("Expected data to survive round trip")
+	}
+	_ = reopened.Close()
+
+	contents, _ := os.ReadFile(file)
+	contents[len(contents)-3] ^= 0xff
+	_ = os.WriteFile(file, contents, 0644)
+
+	if _, err := smalldb.Open[User](file, smalldb.WithChecksum()); !errors.Is(err, smalldb.ErrCorrupted) {
+		t.Fatalf("Expected ErrCorrupted, got %v", err)
+	}
+
+	_ = os.WriteFile(file, contents[:len(contents)/2], 0644)
+	if _, err := smalldb.Open[User](file); !errors.Is(err, smalldb.ErrCorrupted) {
+		t.Fatalf("Expected ErrCorrupted for truncated file, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package smalldb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec serializes the database contents to and from bytes.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes data as JSON. It is the default codec.
+// Prefix and Indent behave as in json.MarshalIndent; leave both empty for compact output.
+// Keys are always written in sorted order, so unchanged data produces a
+// byte-identical file that diffs cleanly under version control.
+type JSONCodec struct {
+	Prefix string
+	Indent string
+}
+
+// Marshal encodes v as JSON.
+func (c JSONCodec) Marshal(v any) ([]byte, error) {
+	if c.Prefix == "" && c.Indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, c.Prefix, c.Indent)
+}
+
+// Unmarshal decodes JSON data into v.
+func (c JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes data using encoding/gob.
+// Only exported fields are stored, and interface values must be registered with gob.Register.
+type GobCodec struct{}
+
+// Marshal encodes v using gob.
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v.
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,48 @@
+package smalldb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+type account struct {
+	Owner   string
+	Balance int64
+	Tags    []string
+	secret  string
+}
+
+func TestGobCodecRoundTrip(t *testing.T) {
+	file := "test_db.gob"
+	defer cleanup(file)
+
+	db, err := smalldb.Open[account](file, smalldb.WithCodec(smalldb.GobCodec{}))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	acc := account{Owner: "Alice", Balance: 42, Tags: []string{"a", "b"}, secret: "hidden"}
+	if err := db.Set("acc:1", acc); err != nil {
+		t.Fatalf("Failed to set data: %v", err)
+	}
+
+	_ = db.Close()
+	reopened, err := smalldb.Open[account](file, smalldb.WithCodec(smalldb.GobCodec{}))
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+
+	got, exists := reopened.Get("acc:1")
+	if !exists {
+		t.Fatalf("Expected key to exist after reopen")
+	}
+
+	acc.secret = "" // Unexported fields are not encoded.
+	if !reflect.DeepEqual(acc, got) {
+		t.Fatalf("Expected %v, got %v", acc, got)
+	}
+}
@@ -0,0 +1,118 @@
+package smalldb
+
+import "strings"
+
+// CollectionSeparator separates a collection name from the keys inside it.
+// An entry with key "1" in collection "users" is stored under "users/1".
+const CollectionSeparator = "/"
+
+// Collection is a named group of entries stored in the same file as the rest of
+// the database. It is a typed view over the keys prefixed with its name, so
+// collections share the database's value type T and can be changed together
+// atomically with Transaction and Tx.Collection.
+//
+// To keep unrelated entity types in one file, either make T a struct that can
+// hold each of them, or use DB[json.RawMessage] and decode values per collection.
+// The first keeps compile-time type safety; the second moves type checks to runtime.
+type Collection[T any] struct {
+	db     *DB[T]
+	prefix string
+}
+
+// Collection returns a handle to the named collection. Collections need not be
+// created before use; one exists as long as it holds entries.
+func (db *DB[T]) Collection(name string) *Collection[T] {
+	return &Collection[T]{db: db, prefix: name + CollectionSeparator}
+}
+
+// Get retrieves the value associated with the given key in the collection.
+func (c *Collection[T]) Get(key string) (T, bool) {
+	return c.db.Get(c.prefix + key)
+}
+
+// Has reports whether the given key exists in the collection.
+func (c *Collection[T]) Has(key string) bool {
+	return c.db.Has(c.prefix + key)
+}
+
+// Set sets the value for the given key in the collection.
+func (c *Collection[T]) Set(key string, value T) error {
+	return c.db.Set(c.prefix+key, value)
+}
+
+// Delete removes the given key from the collection.
+func (c *Collection[T]) Delete(key string) error {
+	return c.db.Delete(c.prefix + key)
+}
+
+// GetAll returns a copy of every entry in the collection, keyed without the collection prefix.
+func (c *Collection[T]) GetAll() map[string]T {
+	return trimKeys(c.db.Scan(c.prefix), c.prefix)
+}
+
+// Keys returns the keys in the collection, without the collection prefix, in no particular order.
+func (c *Collection[T]) Keys() []string {
+	keys := c.db.ScanKeys(c.prefix)
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, c.prefix)
+	}
+	return keys
+}
+
+// Len returns the number of entries in the collection.
+func (c *Collection[T]) Len() int {
+	return len(c.db.ScanKeys(c.prefix))
+}
+
+// TxCollection is a view of a collection within a transaction.
+type TxCollection[T any] struct {
+	tx     *Tx[T]
+	prefix string
+}
+
+// Collection returns a view of the named collection within the transaction.
+// Changes to several collections in one transaction are committed together.
+func (tx *Tx[T]) Collection(name string) *TxCollection[T] {
+	return &TxCollection[T]{tx: tx, prefix: name + CollectionSeparator}
+}
+
+// Get retrieves the value associated with the given key in the collection.
+func (c *TxCollection[T]) Get(key string) (T, bool) {
+	return c.tx.Get(c.prefix + key)
+}
+
+// Has reports whether the given key exists in the collection.
+func (c *TxCollection[T]) Has(key string) bool {
+	return c.tx.Has(c.prefix + key)
+}
+
+// Set sets the value for the given key in the collection.
+func (c *TxCollection[T]) Set(key string, value T) {
+	c.tx.Set(c.prefix+key, value)
+}
+
+// Delete removes the given key from the collection.
+func (c *TxCollection[T]) Delete(key string) {
+	c.tx.Delete(c.prefix + key)
+}
+
+// GetAll returns a copy of every entry in the collection as seen by the
+// transaction, keyed without the collection prefix.
+func (c *TxCollection[T]) GetAll() map[string]T {
+	all := make(map[string]T)
+	for k, v := range c.tx.data {
+		if strings.HasPrefix(k, c.prefix) && !c.tx.isExpired(k) {
+			all[strings.TrimPrefix(k, c.prefix)] = v
+		}
+	}
+	return all
+}
+
+// trimKeys returns a copy of m with prefix removed from every key.
+func trimKeys[T any](m map[string]T, prefix string) map[string]T {
+	trimmed := make(map[string]T, len(m))
+	for k, v := range m {
+		trimmed[strings.TrimPrefix(k, prefix)] = v
+	}
+	return trimmed
+}
@@ -0,0 +1,42 @@
+package smalldb_test
+
+import (
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestCollections(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	users := db.Collection("users")
+	admins := db.Collection("admins")
+
+	_ = users.Set("1", User{Name: "Alice", Age: 30})
+	_ = admins.Set("1", User{Name: "Root", Age: 99})
+
+	if user, _ := users.Get("1"); user.Name != "Alice" {
+		t.Fatalf("Expected Alice, got %v", user)
+	}
+	if admin, _ := admins.Get("1"); admin.Name != "Root" {
+		t.Fatalf("Expected Root, got %v", admin)
+	}
+
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		alice, _ := tx.Collection("users").Get("1")
+		tx.Collection("users").Delete("1")
+		tx.Collection("admins").Set("2", alice)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if users.Len() != 0 {
+		t.Fatalf("Expected users to be empty, got %v", users.GetAll())
+	}
+
+	all := admins.GetAll()
+	if len(all) != 2 || all["2"].Name != "Alice" {
+		t.Fatalf("Expected Alice to be promoted, got %v", all)
+	}
+}
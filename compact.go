@@ -0,0 +1,52 @@
+package smalldb
+
+import (
+	"os"
+	"time"
+)
+
+// Compact rewrites the database file as a clean snapshot of the in-memory
+// state, truncates the write-ahead log and removes any temporary file left next
+// to the database file by an interrupted write, all under the write lock. It
+// returns the number of bytes reclaimed on disk, which is zero if the files did
+// not shrink.
+// In-memory databases have nothing to compact, and with WithStorage Compact
+// rewrites the stored data and always reports zero.
+func (db *DB[T]) Compact() (int64, error) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return 0, err
+	}
+	if db.cfg.memory {
+		return 0, nil
+	}
+	if db.cfg.storage != nil {
+		return 0, db.flush()
+	}
+
+	start := time.Now()
+	before := db.diskUsage()
+	if err := os.Remove(db.filepath + ".tmp"); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if err := db.flush(); err != nil {
+		return 0, err
+	}
+	reclaimed := max(before-db.diskUsage(), 0)
+	db.cfg.logTiming("compact", db.filepath, start, "reclaimed", reclaimed)
+	return reclaimed, nil
+}
+
+// diskUsage returns the combined size of the database file, its write-ahead
+// log and any leftover temporary file.
+func (db *DB[T]) diskUsage() int64 {
+	var total int64
+	for _, path := range []string{db.filepath, db.filepath + ".wal", db.filepath + ".tmp"} {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
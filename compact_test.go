@@ -0,0 +1,31 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestCompactReclaimsSpace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	defer db.Close()
+	for i := 0; i < 10; i++ {
+		_ = db.Set("user:1", User{Name: "Alice", Age: i})
+	}
+	_ = os.WriteFile(file+".tmp", []byte("leftover"), 0644)
+
+	reclaimed, err := db.Compact()
+	if err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if reclaimed <= 0 {
+		t.Fatalf("Expected space to be reclaimed, got %d", reclaimed)
+	}
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("Expected the leftover temp file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package smalldb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Compression selects how the database file is compressed on disk.
+// It is independent of the codec, so any codec can be combined with any compression.
+type Compression int
+
+const (
+	// NoCompression stores the encoded data as is. It is the default.
+	NoCompression Compression = iota
+	// Gzip compresses the encoded data with gzip.
+	Gzip
+)
+
+// gzipMagic is the header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzip reports whether data looks like a gzip stream.
+func isGzip(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+// gunzipBytes decompresses a gzip stream.
+func gunzipBytes(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
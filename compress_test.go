@@ -0,0 +1,54 @@
+package smalldb_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestCompression(t *testing.T) {
+	dir := t.TempDir()
+	plainFile := filepath.Join(dir, "plain.json")
+	gzipFile := filepath.Join(dir, "gzip.json")
+
+	items := make(map[string]User)
+	for i := 0; i < 200; i++ {
+		items[fmt.Sprintf("user:%d", i)] = User{Name: "Alice Wonderland", Age: 30}
+	}
+
+	plain, _ := smalldb.Open[User](plainFile)
+	_ = plain.SetMany(items)
+	_ = plain.Close()
+
+	compressed, _ := smalldb.Open[User](gzipFile, smalldb.WithCompression(smalldb.Gzip))
+	_ = compressed.SetMany(items)
+	_ = compressed.Close()
+
+	plainInfo, _ := os.Stat(plainFile)
+	gzipInfo, _ := os.Stat(gzipFile)
+	if gzipInfo.Size()*4 > plainInfo.Size() {
+		t.Fatalf("Expected compressed file to be much smaller: %d vs %d bytes", gzipInfo.Size(), plainInfo.Size())
+	}
+
+	reopened, err := smalldb.Open[User](gzipFile, smalldb.WithCompression(smalldb.Gzip))
+	if err != nil {
+		t.Fatalf("Failed to reopen compressed database: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != len(items) {
+		t.Fatalf("Expected %d entries, got %d", len(items), reopened.Len())
+	}
+
+	// An existing uncompressed file is still readable with compression enabled.
+	upgraded, err := smalldb.Open[User](plainFile, smalldb.WithCompression(smalldb.Gzip))
+	if err != nil {
+		t.Fatalf("Failed to open uncompressed file with compression enabled: %v", err)
+	}
+	defer upgraded.Close()
+	if upgraded.Len() != len(items) {
+		t.Fatalf("Expected %d entries, got %d", len(items), upgraded.Len())
+	}
+}
@@ -0,0 +1,50 @@
+package smalldb
+
+import "context"
+
+// lockContext acquires db.mu for writing, giving up with ctx.Err() if ctx is
+// done first. Contexts that can never be cancelled take the lock directly.
+func (db *DB[T]) lockContext(ctx context.Context) error {
+	return acquireContext(ctx, db.mu.Lock, db.mu.TryLock, db.mu.Unlock)
+}
+
+// rlockContext acquires db.mu for reading, giving up with ctx.Err() if ctx is done first.
+func (db *DB[T]) rlockContext(ctx context.Context) error {
+	return acquireContext(ctx, db.mu.RLock, db.mu.TryRLock, db.mu.RUnlock)
+}
+
+// acquireContext takes a lock with lock, giving up with ctx.Err() if ctx is
+// done first. When the lock is not free and ctx can be cancelled, lock is
+// called on a helper goroutine so the caller keeps its place in line: a writer
+// blocked in Lock stops new readers from entering, which polling tryLock would
+// not. If ctx wins, the helper releases the lock with unlock once it gets it.
+func acquireContext(ctx context.Context, lock func(), tryLock func() bool, unlock func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if ctx.Done() == nil {
+		lock()
+		return nil
+	}
+	if tryLock() {
+		return nil
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-acquired
+			unlock()
+		}()
+		return ctx.Err()
+	}
+}
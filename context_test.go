@@ -0,0 +1,91 @@
+package smalldb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, opts := range [][]smalldb.Option{nil, {smalldb.WithCOW()}} {
+		db := smalldb.OpenMemory[User](opts...)
+		_ = db.Set("user:2", User{Name: "Bob"})
+
+		if err := db.SetContext(ctx, "user:1", User{Name: "Alice"}); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		if db.Has("user:1") {
+			t.Fatalf("Expected cancelled Set not to mutate state")
+		}
+
+		if _, _, err := db.GetContext(ctx, "user:2"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled from GetContext, got %v", err)
+		}
+		_ = db.Close()
+	}
+}
+
+func TestContextDeadlineWhileWaitingForLock(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := db.TransactionContext(ctx, func(tx *smalldb.Tx[User]) error {
+		t.Errorf("Expected fn not to run")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestContextDeadlineReleasesLockLater(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := db.SetContext(ctx, "user:1", User{Name: "Alice"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	<-done
+	if err := db.Set("user:2", User{Name: "Bob"}); err != nil {
+		t.Fatalf("Expected the lock to be free after the timed out wait, got %v", err)
+	}
+	if db.Has("user:1") {
+		t.Fatalf("Expected the timed out Set not to be applied")
+	}
+}
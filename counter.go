@@ -0,0 +1,24 @@
+package smalldb
+
+// Number is the set of types that Add can operate on.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Add atomically adds delta to the number stored under key and returns the
+// new value. Missing keys start from zero. The read, add and persist happen
+// under a single write lock, so concurrent calls never lose increments.
+func Add[N Number](db *DB[N], key string, delta N) (N, error) {
+	var result N
+	err := db.Update(key, func(old N, _ bool) (N, error) {
+		result = old + delta
+		return result, nil
+	})
+	if err != nil {
+		var zero N
+		return zero, err
+	}
+	return result, nil
+}
@@ -0,0 +1,32 @@
+package smalldb_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestAdd(t *testing.T) {
+	db := smalldb.OpenMemory[int64]()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := smalldb.Add(db, "views", 2); err != nil {
+				t.Errorf("Add failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, err := smalldb.Add(db, "views", -1)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if value != 99 {
+		t.Fatalf("Expected 99, got %d", value)
+	}
+}
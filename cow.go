@@ -0,0 +1,76 @@
+package smalldb
+
+import (
+	"context"
+	"time"
+)
+
+// WithCOW makes Get, GetContext, Has and GetAll lock-free. After every
+// operation that takes the write lock, a copy of the data is published
+// atomically, and those reads use the latest copy without ever waiting for a
+// writer. The cost moves to writes: each one copies the whole dataset, so this
+// suits read-heavy databases that are small or rarely written. Values are
+// copied shallowly, as with GetAll.
+func WithCOW() Option {
+	return func(c *config) {
+		c.cow = true
+	}
+}
+
+// dataView is the data a read works on: either a copy published for WithCOW,
+// or the live data with db.mu held for reading.
+type dataView[T any] struct {
+	data    map[string]T
+	expires map[string]time.Time
+	closed  bool
+	locked  bool
+}
+
+// isExpired reports whether the key has an expiry at or before now.
+func (v *dataView[T]) isExpired(key string, now time.Time) bool {
+	expiry, ok := v.expires[key]
+	return ok && !now.Before(expiry)
+}
+
+// readView returns the data to read. With WithCOW it is the latest published
+// copy and no lock is taken; otherwise db.mu is held for reading. Either way it
+// gives up with ctx.Err() if ctx is done first. The caller must pass the view to
+// releaseView.
+func (db *DB[T]) readView(ctx context.Context) (dataView[T], error) {
+	if view := db.view.Load(); view != nil {
+		if err := ctx.Err(); err != nil {
+			return dataView[T]{}, err
+		}
+		return *view, nil
+	}
+	if err := db.rlockContext(ctx); err != nil {
+		return dataView[T]{}, err
+	}
+	return dataView[T]{data: db.data, expires: db.expires, closed: db.closed, locked: true}, nil
+}
+
+// releaseView releases the read lock taken by readView, if any.
+func (db *DB[T]) releaseView(view dataView[T]) {
+	if view.locked {
+		db.mu.RUnlock()
+	}
+}
+
+// unlock releases the write lock, first publishing a copy of the data for
+// lock-free reads if WithCOW is set.
+func (db *DB[T]) unlock() {
+	if db.cfg.cow {
+		db.publishView()
+	}
+	db.mu.Unlock()
+}
+
+// publishView publishes a copy of the current data for lock-free reads.
+// The caller must hold db.mu for writing or have exclusive access to db.
+func (db *DB[T]) publishView() {
+	db.view.Store(&dataView[T]{
+		data:    cloneMap(db.data),
+		expires: cloneMap(db.expires),
+		closed:  db.closed,
+	})
+}
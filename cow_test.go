@@ -0,0 +1,52 @@
+package smalldb_test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestCOWReads(t *testing.T) {
+	db := smalldb.OpenMemory[int](smalldb.WithCOW())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = db.Set(fmt.Sprintf("w%d:%d", i, j), j)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				db.Get("w0:0")
+				db.Has("w1:1")
+				db.GetAll()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if db.Len() != 200 || len(db.GetAll()) != 200 {
+		t.Fatalf("Expected 200 entries, got %d", len(db.GetAll()))
+	}
+
+	_ = db.Set("a", 1)
+	if value, ok := db.Get("a"); !ok || value != 1 {
+		t.Fatalf("Expected a write to be visible to the next read, got %d (found: %v)", value, ok)
+	}
+	_ = db.Delete("a")
+	if db.Has("a") {
+		t.Fatal("Expected a delete to be visible to the next read")
+	}
+
+	_ = db.Close()
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{}) {
+		t.Fatalf("Expected a closed database to read as empty, got %d entries", len(got))
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package smalldb
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDevice reports whether err is a rename failing because its source
+// and target are on different filesystems.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,8 @@
+//go:build plan9
+
+package smalldb
+
+// isCrossDevice is always false on Plan 9, which has no EXDEV error.
+func isCrossDevice(err error) bool {
+	return false
+}
@@ -0,0 +1,212 @@
+package smalldb
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// csvKeyColumn is the name of the column holding each entry's key.
+const csvKeyColumn = "key"
+
+// ExportCSV writes the database to w as CSV for interchange with spreadsheets.
+// T must be a flat struct: the first column holds the key and every other
+// column one exported field of a string, bool or numeric kind. A `csv` struct
+// tag renames a column, and `csv:"-"` leaves the field out. Rows are sorted by key.
+func (db *DB[T]) ExportCSV(w io.Writer) error {
+	fields, err := csvFields[T]()
+	if err != nil {
+		return err
+	}
+
+	data := db.GetAll()
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cw := csv.NewWriter(w)
+	header := []string{csvKeyColumn}
+	for _, f := range fields {
+		header = append(header, f.name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		v := reflect.ValueOf(data[k])
+		row := []string{k}
+		for _, f := range fields {
+			row = append(row, formatCSVValue(v.Field(f.index)))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportCSV reads entries written by ExportCSV from r and stores them with a
+// single persist, overwriting existing keys. Columns are matched to fields by
+// name, so their order does not matter and unknown columns are rejected.
+// Nothing is stored if any row fails to parse.
+func (db *DB[T]) ImportCSV(r io.Reader) error {
+	fields, err := csvFields[T]()
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]csvField, len(fields))
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	if len(header) == 0 || header[0] != csvKeyColumn {
+		return fmt.Errorf("smalldb: CSV header must start with a %q column", csvKeyColumn)
+	}
+
+	columns := make([]csvField, len(header)-1)
+	for i, name := range header[1:] {
+		f, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("smalldb: CSV column %q does not match any field of %s", name, reflect.TypeFor[T]())
+		}
+		columns[i] = f
+	}
+
+	items := make(map[string]T)
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var value T
+		v := reflect.ValueOf(&value).Elem()
+		for i, f := range columns {
+			if err := parseCSVValue(v.Field(f.index), row[i+1]); err != nil {
+				return fmt.Errorf("smalldb: CSV key %q column %q: %w", row[0], f.name, err)
+			}
+		}
+		items[row[0]] = value
+	}
+
+	return db.SetMany(items)
+}
+
+// csvField maps a CSV column to a struct field.
+type csvField struct {
+	name  string
+	index int
+}
+
+// csvFields lists the CSV columns for T, or explains why T is not a flat struct.
+func csvFields[T any]() ([]csvField, error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("smalldb: CSV requires a flat struct value type, got %s", t)
+	}
+
+	var fields []csvField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+
+		if !isFlatKind(sf.Type.Kind()) {
+			return nil, fmt.Errorf("smalldb: CSV requires a flat struct, field %s of %s has kind %s", sf.Name, t, sf.Type.Kind())
+		}
+		fields = append(fields, csvField{name: name, index: i})
+	}
+	return fields, nil
+}
+
+// isFlatKind reports whether values of kind k fit in a single CSV cell.
+func isFlatKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// formatCSVValue renders a flat value as a CSV cell.
+func formatCSVValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+	}
+}
+
+// parseCSVValue parses a CSV cell into a flat value.
+func parseCSVValue(v reflect.Value, cell string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		f, err := strconv.ParseFloat(cell, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
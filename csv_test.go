@@ -0,0 +1,44 @@
+package smalldb_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	source := smalldb.OpenMemory[User]()
+	_ = source.SetMany(map[string]User{
+		"user:2": {Name: "Bob, Jr.", Age: 25},
+		"user:1": {Name: "Alice", Age: 30},
+	})
+
+	var buf bytes.Buffer
+	if err := source.ExportCSV(&buf); err != nil {
+		t.Fatalf("Failed to export CSV: %v", err)
+	}
+
+	want := "key,Name,Age\nuser:1,Alice,30\nuser:2,\"Bob, Jr.\",25\n"
+	if buf.String() != want {
+		t.Fatalf("Expected %q, got %q", want, buf.String())
+	}
+
+	target := smalldb.OpenMemory[User]()
+	if err := target.ImportCSV(&buf); err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+
+	if !reflect.DeepEqual(source.GetAll(), target.GetAll()) {
+		t.Fatalf("Expected %v, got %v", source.GetAll(), target.GetAll())
+	}
+}
+
+func TestCSVRejectsNestedTypes(t *testing.T) {
+	db := smalldb.OpenMemory[account]()
+	if err := db.ExportCSV(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "flat struct") {
+		t.Fatalf("Expected flat struct error, got %v", err)
+	}
+}
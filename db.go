@@ -1,9 +1,11 @@
 package smalldb
 
 import (
-	"os"
-	"path/filepath"
+	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // DB represents the small database instance.
@@ -12,90 +14,714 @@ type DB[T any] struct {
 	filepath string
 	mu       sync.RWMutex
 	data     map[string]T
+	expires  map[string]time.Time
+	closed   bool
+	cfg      config
+	lock     *fileLock
+	modTime  time.Time
+	size     int64
+	dirty    bool
+	sweeping bool
+	stop     chan struct{}
+	wg       sync.WaitGroup
+
+	subMu      sync.Mutex
+	subs       map[*subscriber[T]]struct{}
+	subsClosed bool
+
+	validator func(key string, value T) error
+	equality  func(a, b T) bool
+	hooks     hooks[T]
+	indexes   map[string]*index[T]
+	wal       *walLog
+	counters  counters
+	lru       *lru
+	report    LoadReport
+	changes   changeLog[T]
+
+	invariants []func(all map[string]T) error
+
+	view atomic.Pointer[dataView[T]]
 }
 
 // Open initializes the database at the given file path.
 // It creates the file and necessary directories if they don't exist.
-func Open[T any](fp string) (*DB[T], error) {
-	err := os.MkdirAll(filepath.Dir(fp), 0755)
-	if err != nil {
+// An advisory lock is held on the file until Close; if another instance
+// already holds it, Open returns ErrLocked. With WithReadOnly or WithStorage
+// nothing is created and a missing file opens as an empty database.
+// Open returns ErrPathIsDirectory if fp is a directory, and ErrPermission if
+// files cannot be created next to it.
+// Options may be supplied to change the defaults.
+func Open[T any](fp string, opts ...Option) (*DB[T], error) {
+	start := time.Now()
+	cfg := newConfig(opts)
+	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
+	if cfg.marshalCheck {
+		if err := checkMarshal[T](&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if cfg.storage == nil {
+		if err = preparePath(fp, &cfg); err != nil {
+			return nil, err
+		}
+	}
 
-	data, err := readData[T](fp)
+	var lock *fileLock
+	if !cfg.noLock && cfg.storage == nil {
+		lock, err = acquireLock(fp, cfg.readOnly, cfg.fileMode)
+		if err != nil {
+			return nil, permissionError(fp+".lock", err)
+		}
+	}
+
+	env, records, err := loadData[T](fp, &cfg)
 	if err != nil {
+		lock.release()
 		return nil, err
 	}
 
-	return &DB[T]{
+	db := newDB(fp, env, cfg)
+	db.lock = lock
+	if db.wal != nil {
+		db.wal.records = records
+	}
+	cfg.logTiming("open", fp, start, "keys", len(env.Data))
+	return db, nil
+}
+
+// OpenMemory returns a database that behaves like one returned by Open but
+// is never written to disk. It is useful for tests and ephemeral data.
+func OpenMemory[T any](opts ...Option) *DB[T] {
+	cfg := newConfig(opts)
+	cfg.memory = true
+
+	env := &envelope[T]{
+		Data:    make(map[string]T, max(cfg.capacity, 0)),
+		Expires: make(map[string]time.Time),
+	}
+	return newDB("", env, cfg)
+}
+
+// newDB builds a database from loaded data and starts its background workers.
+func newDB[T any](fp string, env *envelope[T], cfg config) *DB[T] {
+	db := &DB[T]{
 		filepath: fp,
-		data:     data,
-	}, nil
+		data:     env.Data,
+		expires:  env.Expires,
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+
+		report:    LoadReport{Quarantined: env.quarantined},
+		validator: typedOption[func(string, T) error](cfg.validator, "WithValidator"),
+		equality:  typedOption[func(a, b T) bool](cfg.equality, "WithEquality"),
+		hooks:     newHooks[T](&cfg),
+
+		invariants: newInvariants[T](&cfg),
+		changes:    newChangeLog[T](cfg.changeLog),
+	}
+
+	if !cfg.memory && cfg.storage == nil {
+		db.recordFileState()
+	}
+
+	if cfg.wal && !cfg.memory {
+		db.wal = &walLog{path: fp + ".wal", sync: cfg.sync}
+	}
+
+	if cfg.maxEntries > 0 {
+		db.lru = &lru{}
+		db.resetLRU()
+	}
+
+	if cfg.cow {
+		db.publishView()
+	}
+
+	if cfg.async > 0 && !cfg.memory {
+		db.wg.Add(1)
+		go db.flushLoop(cfg.async)
+	}
+
+	if cfg.reload > 0 && !cfg.memory {
+		db.wg.Add(1)
+		go db.watchLoop(cfg.reload)
+	}
+
+	if cfg.snapshotInterval > 0 {
+		db.wg.Add(1)
+		go db.snapshotLoop(cfg.snapshotInterval)
+	}
+
+	db.purgeExpired(time.Now())
+	if len(db.expires) > 0 {
+		db.startSweeper()
+	}
+
+	return db
 }
 
 // Get retrieves the value associated with the given key.
 // Returns the value and a boolean indicating whether the key exists.
+// Expired keys are reported as missing.
 func (db *DB[T]) Get(key string) (T, bool) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-
-	value, exists := db.data[key]
+	value, exists, _ := db.GetContext(context.Background(), key)
 	return value, exists
 }
 
-// Set sets the value for the given key.
+// GetDefault returns the value associated with the given key, or def if the
+// key is missing or has expired.
+func (db *DB[T]) GetDefault(key string, def T) T {
+	if value, exists := db.Get(key); exists {
+		return value
+	}
+	return def
+}
+
+// GetContext is like Get but gives up waiting for the lock when ctx is done,
+// returning ctx.Err(). A closed database reports the key as missing.
+func (db *DB[T]) GetContext(ctx context.Context, key string) (T, bool, error) {
+	var zero T
+	view, err := db.readView(ctx)
+	if err != nil {
+		return zero, false, err
+	}
+	if view.closed {
+		db.releaseView(view)
+		return zero, false, nil
+	}
+
+	value, exists := view.data[key]
+	expired := exists && view.isExpired(key, time.Now())
+	db.releaseView(view)
+	db.counters.reads.Add(1)
+	if exists && !expired {
+		db.trackAccess(key)
+	}
+
+	if expired {
+		db.removeExpired(key)
+		return zero, false, nil
+	}
+	return value, exists, nil
+}
+
+// Has reports whether the given key exists without copying its value.
+func (db *DB[T]) Has(key string) bool {
+	view, _ := db.readView(context.Background())
+	defer db.releaseView(view)
+
+	if view.closed {
+		return false
+	}
+
+	db.counters.reads.Add(1)
+	_, exists := view.data[key]
+	return exists && !view.isExpired(key, time.Now())
+}
+
+// Set sets the value for the given key, clearing any TTL the key had.
 // This operation is thread-safe.
 func (db *DB[T]) Set(key string, value T) error {
+	return db.SetContext(context.Background(), key, value)
+}
+
+// SetContext is like Set but gives up waiting for the lock when ctx is done,
+// returning ctx.Err() without changing anything.
+func (db *DB[T]) SetContext(ctx context.Context, key string, value T) error {
+	if err := db.lockContext(ctx); err != nil {
+		return err
+	}
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if err := db.validateValue(key, value); err != nil {
+		return err
+	}
+	if err := db.checkChange(map[string]T{key: value}); err != nil {
+		return err
+	}
+
+	saved := db.saveForRollback(key)
+	db.data[key] = value
+	delete(db.expires, key)
+	if err := db.commit([]Event[T]{{Key: key, Value: value, Op: OpSet}}, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
+}
+
+// Update atomically replaces the value for the given key with the result of fn.
+// fn receives the current value, or the zero value and false if the key is missing,
+// and the write lock is held throughout so no other write can interleave.
+// If fn returns an error nothing is changed and the error is returned.
+// This is the safe way to perform read-modify-write operations; any TTL on the key is preserved.
+func (db *DB[T]) Update(key string, fn func(old T, exists bool) (T, error)) error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	db.purgeExpired(time.Now())
+	old, exists := db.data[key]
+	value, err := fn(old, exists)
+	if err != nil {
+		return err
+	}
+	if err := db.validateValue(key, value); err != nil {
+		return err
+	}
+	if err := db.checkChange(map[string]T{key: value}); err != nil {
+		return err
+	}
 
+	saved := db.saveForRollback(key)
 	db.data[key] = value
-	return db.persist()
+	if err := db.commit([]Event[T]{{Key: key, Value: value, Op: OpSet}}, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
 }
 
 // Delete removes the value associated with the given key.
-// This operation is thread-safe.
+// Deleting a missing key succeeds unless strict mode is enabled, in which case
+// it returns ErrKeyNotFound. This operation is thread-safe.
 func (db *DB[T]) Delete(key string) error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
 
+	db.purgeExpired(time.Now())
+	_, existed := db.data[key]
+	if !existed && db.cfg.strict {
+		return ErrKeyNotFound
+	}
+	if existed {
+		if err := db.checkChange(nil, key); err != nil {
+			return err
+		}
+	}
+
+	saved := db.saveForRollback(key)
 	delete(db.data, key)
-	return db.persist()
+	delete(db.expires, key)
+
+	var events []Event[T]
+	if existed {
+		events = []Event[T]{{Key: key, Op: OpDelete}}
+	}
+	if err := db.commit(events, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
 }
 
-// GetAll returns a copy of all key-value pairs in the database.
-func (db *DB[T]) GetAll() map[string]T {
+// Clear removes all entries from the database.
+// Maps previously returned by GetAll are copies and are unaffected.
+func (db *DB[T]) Clear() error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	if err := db.checkInvariants(map[string]T{}); err != nil {
+		return err
+	}
+
+	oldData, oldExpires := db.data, db.expires
+	events := db.diffEvents(oldData, nil, oldExpires, nil, allKeys(oldData))
+	db.data = make(map[string]T)
+	db.expires = make(map[string]time.Time)
+	if err := db.commit(events, nil); err != nil {
+		db.rollbackSwap(oldData, oldExpires)
+		return err
+	}
+	return nil
+}
+
+// Replace atomically swaps the entire contents of the database for a copy of
+// data and persists once, clearing every TTL. Readers see either the old or the
+// new data, never an empty database in between. If persisting fails the old
+// data is kept.
+func (db *DB[T]) Replace(data map[string]T) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if err := db.validateKeys(data, allKeys(data)); err != nil {
+		return err
+	}
+	if err := db.checkInvariants(data); err != nil {
+		return err
+	}
+
+	oldData, oldExpires := db.data, db.expires
+	db.data = cloneMap(data)
+	db.expires = make(map[string]time.Time)
+
+	events := db.diffEvents(oldData, db.data, oldExpires, db.expires, allKeys(oldData, db.data))
+	if err := db.commit(events, oldData); err != nil {
+		db.restoreSwap(oldData, oldExpires)
+		return err
+	}
+	return nil
+}
+
+// GetMany returns the values for the given keys under a single read lock.
+// Keys that are missing or expired are absent from the result.
+func (db *DB[T]) GetMany(keys []string) map[string]T {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	dataCopy := make(map[string]T, len(db.data))
-	for k, v := range db.data {
+	result := make(map[string]T, len(keys))
+	if db.closed {
+		return result
+	}
+
+	db.counters.reads.Add(uint64(len(keys)))
+	now := time.Now()
+	for _, k := range keys {
+		if value, exists := db.data[k]; exists && !db.isExpired(k, now) {
+			result[k] = value
+		}
+	}
+	return result
+}
+
+// GetConsistent returns the values for the given keys as they all were at the
+// same instant, so no write can land between reading one key and the next.
+// Keys that are missing or expired are absent from the result. Unlike GetMany
+// it reports a closed database with ErrClosed rather than an empty result.
+func (db *DB[T]) GetConsistent(keys ...string) (map[string]T, error) {
+	view, _ := db.readView(context.Background())
+	defer db.releaseView(view)
+
+	if view.closed {
+		return nil, ErrClosed
+	}
+
+	db.counters.reads.Add(uint64(len(keys)))
+	now := time.Now()
+	result := make(map[string]T, len(keys))
+	for _, k := range keys {
+		if value, exists := view.data[k]; exists && !view.isExpired(k, now) {
+			result[k] = value
+		}
+	}
+	return result, nil
+}
+
+// GetAll returns a copy of all key-value pairs in the database.
+// Expired entries are omitted and a closed database returns an empty map.
+func (db *DB[T]) GetAll() map[string]T {
+	view, _ := db.readView(context.Background())
+	defer db.releaseView(view)
+
+	if view.closed {
+		return map[string]T{}
+	}
+
+	now := time.Now()
+	dataCopy := make(map[string]T, len(view.data))
+	for k, v := range view.data {
+		if view.isExpired(k, now) {
+			continue
+		}
 		dataCopy[k] = v
 	}
 	return dataCopy
 }
 
+// Keys returns a snapshot of all keys in the database in no particular order.
+func (db *DB[T]) Keys() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return []string{}
+	}
+
+	now := time.Now()
+	keys := make([]string, 0, len(db.data))
+	for k := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// SortedKeys returns a snapshot of all keys in the database in ascending lexical order.
+func (db *DB[T]) SortedKeys() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return []string{}
+	}
+
+	return db.sortedKeys()
+}
+
+// Len returns the number of entries in the database.
+func (db *DB[T]) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return 0
+	}
+
+	now := time.Now()
+	count := len(db.data)
+	for k := range db.expires {
+		if db.isExpired(k, now) {
+			count--
+		}
+	}
+	return count
+}
+
 // Transaction provides a function to execute multiple operations atomically.
 // The provided function fn is executed with exclusive access to the database.
+// Changes are committed if fn returns nil, and discarded if fn returns an
+// error or calls tx.Rollback.
+//
+// With WithAutoReload, a transaction starts from the latest contents of the
+// file, and if another process changes the file while fn runs, the changes are
+// discarded, the file is reloaded and ErrConflict is returned rather than the
+// other process's writes being overwritten. TransactionRetry retries on it.
+//
+// fn must use only the tx passed to it, and tx.Transaction to nest a
+// transaction. Calling methods of the database itself from inside fn, or from
+// a callback that runs under the lock such as those passed to Update, Prune or
+// WithOnSet, deadlocks; it is not detected.
 func (db *DB[T]) Transaction(fn func(tx *Tx[T]) error) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
+	return db.TransactionContext(context.Background(), fn)
+}
+
+// TransactionContext is like Transaction but gives up waiting for the lock when
+// ctx is done, returning ctx.Err() without running fn.
+func (db *DB[T]) TransactionContext(ctx context.Context, fn func(tx *Tx[T]) error) error {
+	if err := db.lockContext(ctx); err != nil {
+		return err
+	}
+	defer db.unlock()
 
+	if db.closed {
+		return ErrClosed
+	}
+	if _, err := db.reloadIfChanged(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	db.purgeExpired(now)
 	tx := &Tx[T]{
-		db:   db,
-		data: cloneMap(db.data),
+		db:      db,
+		data:    cloneMap(db.data),
+		expires: cloneMap(db.expires),
+		touched: make(map[string]struct{}),
+		now:     now,
 	}
 
 	if err := fn(tx); err != nil {
 		return err
 	}
+	if tx.rolledBack {
+		return nil
+	}
+	if db.hooks.beforeCommit != nil {
+		if err := db.hooks.beforeCommit(tx); err != nil {
+			return err
+		}
+		if tx.rolledBack {
+			return nil
+		}
+	}
+	if db.cfg.readOnly {
+		if len(tx.touched) > 0 {
+			return ErrReadOnly
+		}
+		return nil
+	}
+	if reloaded, err := db.reloadIfChanged(); err != nil {
+		return err
+	} else if reloaded {
+		return ErrConflict
+	}
+	if err := db.validateKeys(tx.data, tx.touched); err != nil {
+		return err
+	}
+	if err := db.checkInvariants(tx.data); err != nil {
+		return err
+	}
 
 	// Commit changes
+	oldData, oldExpires := db.data, db.expires
+	events := db.diffEvents(oldData, tx.data, oldExpires, tx.expires, tx.touched)
 	db.data = tx.data
-	return db.persist()
+	db.expires = tx.expires
+	if err := db.commit(events, oldData); err != nil {
+		db.rollbackSwap(oldData, oldExpires)
+		return err
+	}
+	return nil
+}
+
+// TransactionRetry runs fn in a transaction like Transaction, running it again
+// with a fresh copy of the data, up to attempts times in all, for as long as it
+// returns an error matching ErrRetry or the transaction fails with ErrConflict.
+// The lock is released between attempts so other writers can make progress. If
+// every attempt asks to retry, the last error is returned.
+func (db *DB[T]) TransactionRetry(attempts int, fn func(tx *Tx[T]) error) error {
+	var err error
+	for range max(attempts, 1) {
+		err = db.Transaction(fn)
+		if !errors.Is(err, ErrRetry) && !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
 }
 
-// persist writes the in-memory data to the JSON file.
-func (db *DB[T]) persist() error {
-	return writeData(db.filepath, db.data)
+// DryRun runs fn in a transaction exactly as Transaction would, including the
+// WithBeforeCommit hook, validators, invariants and WithReadOnly, but instead
+// of committing it discards the changes and returns what they would have been. Only the read lock is held, so dry
+// runs do not block readers. If fn or a check returns an error, it is returned
+// with an empty changeset; a rolled back transaction reports no changes.
+func (db *DB[T]) DryRun(fn func(tx *Tx[T]) error) (Changeset[T], error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return Changeset[T]{}, ErrClosed
+	}
+
+	now := time.Now()
+	before := make(map[string]T, len(db.data))
+	expires := make(map[string]time.Time, len(db.expires))
+	for k, v := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		before[k] = v
+		if expiry, ok := db.expires[k]; ok {
+			expires[k] = expiry
+		}
+	}
+
+	tx := &Tx[T]{
+		db:      db,
+		data:    cloneMap(before),
+		expires: expires,
+		touched: make(map[string]struct{}),
+		now:     now,
+	}
+
+	if err := fn(tx); err != nil {
+		return Changeset[T]{}, err
+	}
+	if !tx.rolledBack && db.hooks.beforeCommit != nil {
+		if err := db.hooks.beforeCommit(tx); err != nil {
+			return Changeset[T]{}, err
+		}
+	}
+	if tx.rolledBack {
+		return DiffFunc(before, before, db.equal), nil
+	}
+	if db.cfg.readOnly && len(tx.touched) > 0 {
+		return Changeset[T]{}, ErrReadOnly
+	}
+	if err := db.validateKeys(tx.data, tx.touched); err != nil {
+		return Changeset[T]{}, err
+	}
+	if err := db.checkInvariants(tx.data); err != nil {
+		return Changeset[T]{}, err
+	}
+	return DiffFunc(before, tx.data, db.equal), nil
+}
+
+// View runs fn in a read-only transaction that sees a consistent view of the database.
+// Only the read lock is held, so views run concurrently with each other.
+// Calling Set or Delete on the transaction panics with ErrReadOnly.
+func (db *DB[T]) View(fn func(tx *Tx[T]) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	tx := &Tx[T]{
+		db:       db,
+		data:     db.data,
+		expires:  db.expires,
+		now:      time.Now(),
+		readOnly: true,
+	}
+	return fn(tx)
+}
+
+// Close flushes any pending state, releases the file lock and marks the database as closed.
+// Subsequent writes return ErrClosed and reads behave as if the key is missing.
+// Closing an already closed database is a no-op and returns nil.
+// If the final flush fails the database stays open and the error is returned.
+func (db *DB[T]) Close() error {
+	db.mu.Lock()
+
+	if db.closed {
+		db.unlock()
+		return nil
+	}
+
+	if db.dirty {
+		if err := db.flush(); err != nil {
+			db.unlock()
+			return err
+		}
+	}
+
+	db.closed = true
+	close(db.stop)
+	db.unlock()
+
+	db.closeSubscribers()
+	db.wg.Wait()
+	if err := db.wal.close(); err != nil {
+		db.lock.release()
+		return err
+	}
+	return db.lock.release()
+}
+
+// writable returns ErrClosed or ErrReadOnly if the database cannot be written.
+// The caller must hold db.mu.
+func (db *DB[T]) writable() error {
+	if db.closed {
+		return ErrClosed
+	}
+	if db.cfg.readOnly {
+		return ErrReadOnly
+	}
+	return nil
 }
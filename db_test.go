@@ -1,8 +1,10 @@
 package smalldb_test
 
 import (
+	"errors"
 	"os"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
 
@@ -18,6 +20,7 @@ type User struct {
 // Helper function to clean up test files
 func cleanup(file string) {
 	_ = os.Remove(file)
+	_ = os.Remove(file + ".lock")
 }
 
 func TestOpen(t *testing.T) {
@@ -28,6 +31,7 @@ func TestOpen(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to open database: %v", err)
 	}
+	defer db.Close()
 
 	if db == nil {
 		t.Fatalf("Expected db instance, got nil")
@@ -39,6 +43,7 @@ func TestSetAndGet(t *testing.T) {
 	defer cleanup(file)
 
 	db, _ := smalldb.Open[User](file)
+	defer db.Close()
 	user := User{Name: "Alice", Age: 30}
 
 	err := db.Set("user:1", user)
@@ -61,6 +66,7 @@ func TestDelete(t *testing.T) {
 	defer cleanup(file)
 
 	db, _ := smalldb.Open[User](file)
+	defer db.Close()
 	user := User{Name: "Bob", Age: 25}
 
 	_ = db.Set("user:2", user)
@@ -80,6 +86,7 @@ func TestGetAll(t *testing.T) {
 	defer cleanup(file)
 
 	db, _ := smalldb.Open[User](file)
+	defer db.Close()
 	users := map[string]User{
 		"user:1": {Name: "Alice", Age: 30},
 		"user:2": {Name: "Bob", Age: 25},
@@ -106,6 +113,7 @@ func TestConcurrentAccess(t *testing.T) {
 	defer cleanup(file)
 
 	db, _ := smalldb.Open[User](file)
+	defer db.Close()
 	user := User{Name: "Charlie", Age: 28}
 	_ = db.Set("user:3", user)
 
@@ -143,6 +151,7 @@ func TestTransaction(t *testing.T) {
 	defer cleanup(file)
 
 	db, _ := smalldb.Open[User](file)
+	defer db.Close()
 
 	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
 		tx.Set("user:4", User{Name: "Dave", Age: 40})
@@ -165,3 +174,289 @@ func TestTransaction(t *testing.T) {
 		t.Fatalf("Expected user:5 to be Eve")
 	}
 }
+
+func TestClose(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Failed to close database: %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Expected double close to return nil, got %v", err)
+	}
+
+	if _, exists := db.Get("user:1"); exists {
+		t.Fatalf("Expected Get on closed database to miss")
+	}
+
+	if err := db.Set("user:2", User{Name: "Bob"}); !errors.Is(err, smalldb.ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Set, got %v", err)
+	}
+
+	if err := db.Delete("user:1"); !errors.Is(err, smalldb.ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Delete, got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[int](file)
+	defer db.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := db.Update("counter", func(old int, exists bool) (int, error) {
+				return old + 1, nil
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count, _ := db.Get("counter"); count != 20 {
+		t.Fatalf("Expected counter to be 20, got %d", count)
+	}
+
+	abort := errors.New("abort")
+	err := db.Update("counter", func(old int, exists bool) (int, error) {
+		return 0, abort
+	})
+	if !errors.Is(err, abort) {
+		t.Fatalf("Expected abort error, got %v", err)
+	}
+
+	if count, _ := db.Get("counter"); count != 20 {
+		t.Fatalf("Expected aborted update to leave counter at 20, got %d", count)
+	}
+}
+
+func TestHas(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	if !db.Has("user:1") {
+		t.Fatalf("Expected user:1 to exist")
+	}
+	if db.Has("user:2") {
+		t.Fatalf("Expected user:2 not to exist")
+	}
+
+	_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Delete("user:1")
+		if tx.Has("user:1") {
+			t.Errorf("Expected deleted key to be absent within transaction")
+		}
+		return nil
+	})
+}
+
+func TestGetMany(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+
+	got := db.GetMany([]string{"user:1", "user:2", "user:missing"})
+	want := map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestGetConsistent(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"balance": 100, "last": 7})
+
+	got, err := db.GetConsistent("balance", "last", "missing")
+	if err != nil {
+		t.Fatalf("GetConsistent failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, map[string]int{"balance": 100, "last": 7}) {
+		t.Fatalf("Unexpected values: %v", got)
+	}
+
+	_ = db.Close()
+	if _, err := db.GetConsistent("balance"); !errors.Is(err, smalldb.ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestGetDefault(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.Set("retries", 5)
+
+	if got := db.GetDefault("retries", 3); got != 5 {
+		t.Fatalf("Expected the stored 5, got %d", got)
+	}
+	if got := db.GetDefault("timeout", 30); got != 30 {
+		t.Fatalf("Expected the default 30, got %d", got)
+	}
+
+	_ = db.View(func(tx *smalldb.Tx[int]) error {
+		if got := tx.GetDefault("timeout", 30); got != 30 {
+			t.Errorf("Expected the default 30 within the transaction, got %d", got)
+		}
+		return nil
+	})
+}
+
+func TestReplace(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	events, unsubscribe := db.Subscribe()
+	defer unsubscribe()
+
+	fresh := map[string]User{"user:2": {Name: "Bob", Age: 25}}
+	if err := db.Replace(fresh); err != nil {
+		t.Fatalf("Failed to replace: %v", err)
+	}
+	fresh["user:3"] = User{Name: "Mallory"}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]User{"user:2": {Name: "Bob", Age: 25}}) {
+		t.Fatalf("Expected only user:2 after replace, got %v", got)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected a delete and a set event, got %d events", len(events))
+	}
+}
+
+func TestKeysAndLen(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+
+	keys := db.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"user:1", "user:2"}) {
+		t.Fatalf("Expected [user:1 user:2], got %v", keys)
+	}
+
+	if db.Len() != 2 {
+		t.Fatalf("Expected length 2, got %d", db.Len())
+	}
+}
+
+func TestClear(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+
+	snapshot := db.GetAll()
+
+	if err := db.Clear(); err != nil {
+		t.Fatalf("Failed to clear database: %v", err)
+	}
+
+	if db.Len() != 0 {
+		t.Fatalf("Expected empty database, got %d entries", db.Len())
+	}
+
+	if len(snapshot) != 2 {
+		t.Fatalf("Expected earlier snapshot to keep 2 entries, got %d", len(snapshot))
+	}
+
+	_ = db.Close()
+	reopened, _ := smalldb.Open[User](file)
+	defer reopened.Close()
+	if reopened.Len() != 0 {
+		t.Fatalf("Expected cleared database to persist, got %d entries", reopened.Len())
+	}
+}
+
+func TestOpenMemory(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", User{Name: "Bob", Age: 25})
+		tx.Delete("user:1")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if db.Has("user:1") || !db.Has("user:2") {
+		t.Fatalf("Expected only user:2 to remain, got %v", db.GetAll())
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Failed to close database: %v", err)
+	}
+}
+
+func TestOpenLocked(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, err := smalldb.Open[User](file)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	if _, err := smalldb.Open[User](file); !errors.Is(err, smalldb.ErrLocked) {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+
+	if _, err := smalldb.Open[User](file, smalldb.WithReadOnly()); !errors.Is(err, smalldb.ErrLocked) {
+		t.Fatalf("Expected ErrLocked for reader while writer holds the lock, got %v", err)
+	}
+
+	_ = db.Close()
+
+	reader1, err := smalldb.Open[User](file, smalldb.WithReadOnly())
+	if err != nil {
+		t.Fatalf("Failed to open first reader: %v", err)
+	}
+	defer reader1.Close()
+
+	reader2, err := smalldb.Open[User](file, smalldb.WithReadOnly())
+	if err != nil {
+		t.Fatalf("Failed to open second reader: %v", err)
+	}
+	defer reader2.Close()
+}
+
+func TestStrictDelete(t *testing.T) {
+	lenient := smalldb.OpenMemory[User]()
+	if err := lenient.Delete("user:missing"); err != nil {
+		t.Fatalf("Expected lenient Delete of missing key to succeed, got %v", err)
+	}
+
+	strict := smalldb.OpenMemory[User](smalldb.WithStrictMode())
+	if err := strict.Delete("user:missing"); !errors.Is(err, smalldb.ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	_ = strict.Set("user:1", User{Name: "Alice"})
+	if err := strict.Delete("user:1"); err != nil {
+		t.Fatalf("Expected Delete of existing key to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package smalldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// diagnoseJSON explains why raw could not be decoded as a database of T,
+// wrapping ErrInvalidFormat with the kind of the top-level value or the key
+// whose value does not match T. It falls back to wrapping cause.
+func diagnoseJSON[T any](raw []byte, cause error) error {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		return fmt.Errorf("%w: expected JSON object at top level, got %s", ErrInvalidFormat, jsonKind(trimmed[0]))
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &values); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if _, ok := values["$smalldb"]; ok {
+		var data map[string]json.RawMessage
+		if err := json.Unmarshal(values["data"], &data); err != nil {
+			return fmt.Errorf("%w: data: %v", ErrInvalidFormat, err)
+		}
+		values = data
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var value T
+		if err := json.Unmarshal(values[k], &value); err != nil {
+			return fmt.Errorf("%w: value of key %q does not match %s: %v", ErrInvalidFormat, k, reflect.TypeFor[T](), err)
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidFormat, cause)
+}
+
+// jsonKind names the kind of JSON value that starts with c.
+func jsonKind(c byte) string {
+	switch {
+	case c == '[':
+		return "array"
+	case c == '"':
+		return "string"
+	case c == 't' || c == 'f':
+		return "boolean"
+	case c == 'n':
+		return "null"
+	case c == '-' || (c >= '0' && c <= '9'):
+		return "number"
+	default:
+		return fmt.Sprintf("invalid character %q", c)
+	}
+}
@@ -0,0 +1,55 @@
+package smalldb
+
+import "reflect"
+
+// Change holds the old and new value of a modified key.
+type Change[T any] struct {
+	Old T
+	New T
+}
+
+// Changeset describes how one state of a database differs from another.
+type Changeset[T any] struct {
+	Added    map[string]T         // Keys only in the newer state, with their values.
+	Removed  map[string]T         // Keys only in the older state, with their last values.
+	Modified map[string]Change[T] // Keys in both states whose values differ.
+}
+
+// IsEmpty reports whether the changeset records no changes.
+func (c Changeset[T]) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Diff compares two states of a database, such as the results of two GetAll
+// calls, and reports what changed going from a to b. Values are compared with
+// reflect.DeepEqual; use DiffFunc to supply a different comparison.
+func Diff[T any](a, b map[string]T) Changeset[T] {
+	return DiffFunc(a, b, func(x, y T) bool {
+		return reflect.DeepEqual(x, y)
+	})
+}
+
+// DiffFunc is like Diff but uses equal to decide whether a value was modified.
+func DiffFunc[T any](a, b map[string]T, equal func(x, y T) bool) Changeset[T] {
+	changes := Changeset[T]{
+		Added:    make(map[string]T),
+		Removed:  make(map[string]T),
+		Modified: make(map[string]Change[T]),
+	}
+
+	for k, old := range a {
+		value, exists := b[k]
+		switch {
+		case !exists:
+			changes.Removed[k] = old
+		case !equal(old, value):
+			changes.Modified[k] = Change[T]{Old: old, New: value}
+		}
+	}
+	for k, value := range b {
+		if _, exists := a[k]; !exists {
+			changes.Added[k] = value
+		}
+	}
+	return changes
+}
@@ -0,0 +1,47 @@
+package smalldb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestDiff(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+	})
+	before := db.GetAll()
+
+	_ = db.Delete("user:1")
+	_ = db.Set("user:2", User{Name: "Bob", Age: 26})
+	_ = db.Set("user:3", User{Name: "Charlie", Age: 28})
+
+	changes := smalldb.Diff(before, db.GetAll())
+	want := smalldb.Changeset[User]{
+		Added:   map[string]User{"user:3": {Name: "Charlie", Age: 28}},
+		Removed: map[string]User{"user:1": {Name: "Alice", Age: 30}},
+		Modified: map[string]smalldb.Change[User]{
+			"user:2": {Old: User{Name: "Bob", Age: 25}, New: User{Name: "Bob", Age: 26}},
+		},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Expected %+v, got %+v", want, changes)
+	}
+
+	if !smalldb.Diff(before, before).IsEmpty() {
+		t.Fatalf("Expected no changes between identical states")
+	}
+}
+
+func TestDiffFunc(t *testing.T) {
+	a := map[string]User{"user:1": {Name: "Alice", Age: 30}}
+	b := map[string]User{"user:1": {Name: "Alice", Age: 31}}
+
+	sameName := func(x, y User) bool { return x.Name == y.Name }
+	if changes := smalldb.DiffFunc(a, b, sameName); !changes.IsEmpty() {
+		t.Fatalf("Expected no changes with custom comparator, got %+v", changes)
+	}
+}
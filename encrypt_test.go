@@ -0,0 +1,46 @@
+package smalldb_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestEncryption(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	key := bytes.Repeat([]byte("k"), 32)
+	db, err := smalldb.Open[User](file, smalldb.WithEncryption(key), smalldb.WithCompression(smalldb.Gzip))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Close()
+
+	contents, _ := os.ReadFile(file)
+	if bytes.Contains(contents, []byte("Alice")) {
+		t.Fatalf("Expected file contents to be encrypted")
+	}
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithEncryption(key), smalldb.WithCompression(smalldb.Gzip))
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if user, _ := reopened.Get("user:1"); user.Name != "Alice" {
+		t.Fatalf("Expected Alice after round trip, got %v", user)
+	}
+	_ = reopened.Close()
+
+	wrongKey := bytes.Repeat([]byte("x"), 32)
+	if _, err := smalldb.Open[User](file, smalldb.WithEncryption(wrongKey)); !errors.Is(err, smalldb.ErrDecryption) {
+		t.Fatalf("Expected ErrDecryption with wrong key, got %v", err)
+	}
+
+	if _, err := smalldb.Open[User](file, smalldb.WithEncryption([]byte("short"))); err == nil {
+		t.Fatalf("Expected invalid key length to be rejected")
+	}
+}
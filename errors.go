@@ -0,0 +1,62 @@
+package smalldb
+
+import "errors"
+
+// Errors returned by the database. They may be wrapped with extra detail, so
+// compare with errors.Is rather than ==.
+var (
+	// ErrClosed is returned by write methods, transactions and Snapshot once
+	// Close has been called. Plain reads report keys as missing instead.
+	ErrClosed = errors.New("smalldb: database is closed")
+
+	// ErrPathIsDirectory is returned by Open when the database path is a directory.
+	ErrPathIsDirectory = errors.New("smalldb: database path is a directory")
+
+	// ErrPermission is returned by Open when the database file, its lock file
+	// or its directory cannot be created or written. It wraps the underlying
+	// error, so errors.Is(err, fs.ErrPermission) also holds.
+	ErrPermission = errors.New("smalldb: permission denied")
+
+	// ErrLocked is returned by Open when another process or instance holds a conflicting lock on the file.
+	ErrLocked = errors.New("smalldb: database is locked by another process")
+
+	// ErrReadOnly is returned by write methods, and by transactions that make
+	// changes, on a database opened with WithReadOnly. Writing through a View
+	// transaction panics with it.
+	ErrReadOnly = errors.New("smalldb: database is read-only")
+
+	// ErrDecryption is returned by Open and Reload when an encrypted file cannot
+	// be decrypted, usually because the key is wrong.
+	ErrDecryption = errors.New("smalldb: unable to decrypt database file")
+
+	// ErrCorrupted is returned by Open, Reload and Verify when the file fails its
+	// checksum or the write-ahead log holds a damaged record.
+	ErrCorrupted = errors.New("smalldb: database file is corrupted")
+
+	// ErrKeyNotFound is returned by Rename when the source key does not exist,
+	// by Touch when the key does not exist, and by Delete in strict mode (see
+	// WithStrictMode).
+	ErrKeyNotFound = errors.New("smalldb: key not found")
+
+	// ErrKeyExists is returned by RenameNX when the target key already exists.
+	ErrKeyExists = errors.New("smalldb: key already exists")
+
+	// ErrInvalidFormat is returned by Open, Reload and Verify when a JSON file does not
+	// hold a database, such as when the top level is not an object or a value
+	// does not match the value type. The message says what was found and where.
+	ErrInvalidFormat = errors.New("smalldb: invalid file format")
+
+	// ErrRetry may be returned, possibly wrapped, by a TransactionRetry function
+	// to have the transaction run again.
+	ErrRetry = errors.New("smalldb: retry transaction")
+
+	// ErrConflict is returned by Transaction on a database opened with
+	// WithAutoReload when another process changed the file while the
+	// transaction ran. The transaction's changes are discarded and the new
+	// contents of the file are loaded, so it can be retried.
+	ErrConflict = errors.New("smalldb: transaction conflicts with a change to the file")
+
+	// ErrTooFarBehind is returned by Changes when changes after the requested
+	// version are no longer kept, so the caller must start again from a full copy.
+	ErrTooFarBehind = errors.New("smalldb: changes since version are no longer available")
+)
@@ -0,0 +1,251 @@
+package smalldb
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Op identifies the kind of change an Event describes.
+type Op int
+
+const (
+	// OpSet means the key was created or its value replaced.
+	OpSet Op = iota + 1
+	// OpDelete means the key was removed.
+	OpDelete
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change to a single key.
+// Value holds the new value for OpSet and the zero value for OpDelete.
+type Event[T any] struct {
+	Key   string
+	Value T
+	Op    Op
+}
+
+// subscriber is a registered receiver of events.
+// match, when set, restricts delivery to keys it accepts. Subscribers created
+// by WatchKey receive values on values instead of events on ch.
+type subscriber[T any] struct {
+	ch     chan Event[T]
+	values chan T
+	match  func(key string) bool
+}
+
+// deliver sends event to the subscriber without blocking, dropping it if the
+// buffer is full. It reports false once the subscriber has finished.
+func (s *subscriber[T]) deliver(event Event[T]) bool {
+	if s.values == nil {
+		select {
+		case s.ch <- event:
+		default: // Drop rather than block the writer.
+		}
+		return true
+	}
+
+	if event.Op == OpDelete {
+		close(s.values)
+		return false
+	}
+	select {
+	case s.values <- event.Value:
+	default:
+	}
+	return true
+}
+
+// close closes the subscriber's channel.
+func (s *subscriber[T]) close() {
+	if s.values != nil {
+		close(s.values)
+		return
+	}
+	close(s.ch)
+}
+
+// Subscribe returns a channel that receives an Event for every change once it
+// has been persisted, and a function that unsubscribes and closes the channel.
+// Transactions emit one event per changed key on commit. Expired entries do
+// not produce events. Delivery never blocks writers: if the channel's buffer
+// (see WithEventBuffer) is full, the event is dropped for that subscriber.
+// The channel is also closed when the database is closed.
+func (db *DB[T]) Subscribe() (<-chan Event[T], func()) {
+	return db.subscribe(nil)
+}
+
+// SubscribePrefix is like Subscribe but only delivers events for keys that
+// start with prefix. Other events are filtered out before they are queued, so
+// they never take up room in the channel's buffer.
+func (db *DB[T]) SubscribePrefix(prefix string) (<-chan Event[T], func()) {
+	return db.subscribe(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// WatchKey returns a channel that receives the new value of key each time it
+// is set, once the change has been persisted, and a function that stops
+// watching and closes the channel. Only changes to key are delivered, so
+// unrelated traffic never fills the buffer (see WithEventBuffer); values are
+// dropped rather than block writers when it is full. Deleting the key closes
+// the channel, so receivers see the zero value with ok set to false.
+// The channel is also closed when the database is closed.
+func (db *DB[T]) WatchKey(key string) (<-chan T, func()) {
+	sub := &subscriber[T]{
+		values: make(chan T, db.cfg.eventBuffer),
+		match:  func(k string) bool { return k == key },
+	}
+	return sub.values, db.register(sub)
+}
+
+// subscribe registers a subscriber with an optional key filter.
+func (db *DB[T]) subscribe(match func(key string) bool) (<-chan Event[T], func()) {
+	sub := &subscriber[T]{
+		ch:    make(chan Event[T], db.cfg.eventBuffer),
+		match: match,
+	}
+	return sub.ch, db.register(sub)
+}
+
+// register adds sub to the subscribers and returns a function that removes it
+// and closes its channel. After Close the channel is closed immediately.
+func (db *DB[T]) register(sub *subscriber[T]) func() {
+	db.subMu.Lock()
+	defer db.subMu.Unlock()
+
+	if db.subs == nil {
+		db.subs = make(map[*subscriber[T]]struct{})
+	}
+	if db.subsClosed {
+		sub.close()
+		return func() {}
+	}
+	db.subs[sub] = struct{}{}
+
+	return func() {
+		db.subMu.Lock()
+		defer db.subMu.Unlock()
+
+		if _, ok := db.subs[sub]; ok {
+			delete(db.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// publish delivers events to all matching subscribers without blocking.
+func (db *DB[T]) publish(events []Event[T]) {
+	if len(events) == 0 {
+		return
+	}
+
+	db.subMu.Lock()
+	defer db.subMu.Unlock()
+
+	for sub := range db.subs {
+		for _, event := range events {
+			if sub.match != nil && !sub.match(event.Key) {
+				continue
+			}
+			if !sub.deliver(event) {
+				delete(db.subs, sub)
+				break
+			}
+		}
+	}
+}
+
+// hasSubscribers reports whether anyone is listening for events.
+func (db *DB[T]) hasSubscribers() bool {
+	db.subMu.Lock()
+	defer db.subMu.Unlock()
+
+	return len(db.subs) > 0
+}
+
+// closeSubscribers closes every subscriber channel and refuses new subscriptions.
+func (db *DB[T]) closeSubscribers() {
+	db.subMu.Lock()
+	defer db.subMu.Unlock()
+
+	for sub := range db.subs {
+		sub.close()
+	}
+	db.subs = nil
+	db.subsClosed = true
+}
+
+// diffEvents returns the events that turn before into after for the given keys,
+// in sorted key order, given the expiries before and after the change. Keys
+// whose value, by reflect.DeepEqual, and expiry are both unchanged produce no
+// event; a key whose only change is its expiry produces one so it is logged.
+// WithEquality only decides which events are announced (see notable), as every
+// changed key must still be persisted and indexed.
+func (db *DB[T]) diffEvents(before, after map[string]T, beforeExpires, afterExpires map[string]time.Time, keys map[string]struct{}) []Event[T] {
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var events []Event[T]
+	for _, k := range sorted {
+		oldValue, inBefore := before[k]
+		newValue, inAfter := after[k]
+		switch {
+		case inAfter && (!inBefore || !reflect.DeepEqual(oldValue, newValue) || expiryChanged(beforeExpires, afterExpires, k)):
+			events = append(events, Event[T]{Key: k, Value: newValue, Op: OpSet})
+		case !inAfter && inBefore:
+			events = append(events, Event[T]{Key: k, Op: OpDelete})
+		}
+	}
+	return events
+}
+
+// expiryChanged reports whether key has a different expiry, or none, in after
+// than in before.
+func expiryChanged(before, after map[string]time.Time, key string) bool {
+	old, hadExpiry := before[key]
+	expiry, hasExpiry := after[key]
+	return hadExpiry != hasExpiry || !old.Equal(expiry)
+}
+
+// notable returns the events to announce to hooks and subscribers: all of
+// events except those setting a key to a value that WithEquality considers
+// equal to its value in before. A nil before announces everything.
+func (db *DB[T]) notable(events []Event[T], before map[string]T) []Event[T] {
+	if before == nil {
+		return events
+	}
+
+	notable := make([]Event[T], 0, len(events))
+	for _, e := range events {
+		if old, ok := before[e.Key]; ok && e.Op == OpSet && db.equal(old, e.Value) {
+			continue
+		}
+		notable = append(notable, e)
+	}
+	return notable
+}
+
+// allKeys returns the union of the keys of the given maps.
+func allKeys[T any](maps ...map[string]T) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, m := range maps {
+		for k := range m {
+			keys[k] = struct{}{}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,97 @@
+package smalldb_test
+
+import (
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestSubscribe(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	events, unsubscribe := db.Subscribe()
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Delete("user:1")
+	_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", User{Name: "Bob", Age: 25})
+		tx.Set("user:3", User{Name: "Charlie", Age: 28})
+		tx.Delete("user:missing")
+		return nil
+	})
+
+	want := []struct {
+		key string
+		op  smalldb.Op
+	}{
+		{"user:1", smalldb.OpSet},
+		{"user:1", smalldb.OpDelete},
+		{"user:2", smalldb.OpSet},
+		{"user:3", smalldb.OpSet},
+	}
+	for _, w := range want {
+		event := <-events
+		if event.Key != w.key || event.Op != w.op {
+			t.Fatalf("Expected %s %s, got %s %s", w.op, w.key, event.Op, event.Key)
+		}
+	}
+
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Fatalf("Expected channel to be closed after unsubscribe")
+	}
+	unsubscribe() // Safe to call twice.
+}
+
+func TestSubscribePrefix(t *testing.T) {
+	db := smalldb.OpenMemory[User](smalldb.WithEventBuffer(1))
+	events, unsubscribe := db.SubscribePrefix("session:")
+	defer unsubscribe()
+
+	_ = db.Set("user:1", User{Name: "Alice"})
+	_ = db.Set("user:2", User{Name: "Bob"})
+	_ = db.Set("session:1", User{Name: "Alice"})
+
+	event := <-events
+	if event.Key != "session:1" || event.Op != smalldb.OpSet {
+		t.Fatalf("Expected only the session event, got %s %s", event.Op, event.Key)
+	}
+}
+
+func TestSubscribeDropsWhenFull(t *testing.T) {
+	db := smalldb.OpenMemory[int](smalldb.WithEventBuffer(1))
+	events, unsubscribe := db.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < 10; i++ {
+		if err := db.Set("counter", i); err != nil {
+			t.Fatalf("Expected writer not to block, got %v", err)
+		}
+	}
+
+	if event := <-events; event.Value != 0 {
+		t.Fatalf("Expected first event to be kept, got %v", event)
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("Expected later events to be dropped, got %v", event)
+	default:
+	}
+}
+
+func TestWatchKey(t *testing.T) {
+	db := smalldb.OpenMemory[string]()
+
+	values, cancel := db.WatchKey("config:theme")
+	defer cancel()
+
+	_ = db.Set("config:other", "ignored")
+	_ = db.Set("config:theme", "dark")
+	_ = db.Delete("config:theme")
+
+	if value := <-values; value != "dark" {
+		t.Fatalf("Expected dark, got %q", value)
+	}
+	if _, ok := <-values; ok {
+		t.Fatalf("Expected the channel to close when the key is deleted")
+	}
+}
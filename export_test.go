@@ -0,0 +1,15 @@
+package smalldb
+
+// LRULen returns how many keys db tracks for WithMaxEntries.
+func LRULen[T any](db *DB[T]) int {
+	db.lru.mu.Lock()
+	defer db.lru.mu.Unlock()
+	return len(db.lru.elems)
+}
+
+// IndexLen returns how many keys the index called name holds.
+func IndexLen[T any](db *DB[T], name string) int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.indexes[name].byKey)
+}
@@ -0,0 +1,79 @@
+package smalldb
+
+// WithOnSet registers fn to be called for every key a write stores.
+// OnSet and OnDelete hooks run synchronously under the write lock, in key order,
+// only after the change has been persisted (or, with WithAsyncPersist, applied
+// in memory), so they never observe data that failed to save. They run before
+// the same change is published to subscribers and must not call back into the database.
+// T must match the value type of the database being opened.
+func WithOnSet[T any](fn func(key string, value T)) Option {
+	return func(c *config) {
+		c.onSet = fn
+	}
+}
+
+// WithOnDelete registers fn to be called for every key a write removes, with
+// the same ordering guarantees as WithOnSet. Deleting a key that does not
+// exist, and expiry of keys with a TTL, do not call it.
+func WithOnDelete(fn func(key string)) Option {
+	return func(c *config) {
+		c.onDelete = fn
+	}
+}
+
+// WithBeforeCommit registers fn to run inside every Transaction and DryRun
+// after the transaction function returns successfully and before its changes
+// are validated and applied. fn may make further changes through tx or veto the
+// commit by returning an error, which Transaction then returns.
+// T must match the value type of the database being opened.
+func WithBeforeCommit[T any](fn func(tx *Tx[T]) error) Option {
+	return func(c *config) {
+		c.beforeCommit = fn
+	}
+}
+
+// WithOnReload registers fn to be called after Reload, or a reload triggered by
+// WithAutoReload, replaces the data with the contents of the file. fn receives
+// the data from before the reload and a copy of the data loaded, so derived
+// state such as caches can be rebuilt. Like the other hooks it runs under the
+// write lock, before subscribers are notified, and must not call back into the
+// database.
+// T must match the value type of the database being opened.
+func WithOnReload[T any](fn func(old, new map[string]T)) Option {
+	return func(c *config) {
+		c.onReload = fn
+	}
+}
+
+// hooks holds the lifecycle callbacks of a database.
+type hooks[T any] struct {
+	onSet        func(key string, value T)
+	onDelete     func(key string)
+	beforeCommit func(tx *Tx[T]) error
+	onEvict      func(key string, value T)
+	onReload     func(old, new map[string]T)
+}
+
+// newHooks extracts the lifecycle callbacks from cfg.
+func newHooks[T any](cfg *config) hooks[T] {
+	return hooks[T]{
+		onSet:        typedOption[func(string, T)](cfg.onSet, "WithOnSet"),
+		onDelete:     cfg.onDelete,
+		beforeCommit: typedOption[func(*Tx[T]) error](cfg.beforeCommit, "WithBeforeCommit"),
+		onEvict:      typedOption[func(string, T)](cfg.onEvict, "WithOnEvict"),
+		onReload:     typedOption[func(map[string]T, map[string]T)](cfg.onReload, "WithOnReload"),
+	}
+}
+
+// runHooks calls the OnSet and OnDelete hooks for each event, in event order.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) runHooks(events []Event[T]) {
+	for _, e := range events {
+		switch {
+		case e.Op == OpSet && db.hooks.onSet != nil:
+			db.hooks.onSet(e.Key, e.Value)
+		case e.Op == OpDelete && db.hooks.onDelete != nil:
+			db.hooks.onDelete(e.Key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package smalldb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestOnSetAndOnDeleteHooks(t *testing.T) {
+	var calls []string
+	db := smalldb.OpenMemory[User](
+		smalldb.WithOnSet(func(key string, u User) {
+			calls = append(calls, "set "+key+" "+u.Name)
+		}),
+		smalldb.WithOnDelete(func(key string) {
+			calls = append(calls, "delete "+key)
+		}),
+	)
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Delete("user:missing")
+	_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", User{Name: "Bob", Age: 25})
+		tx.Delete("user:1")
+		return nil
+	})
+
+	want := []string{"set user:1 Alice", "delete user:1", "set user:2 Bob"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("Expected %v, got %v", want, calls)
+	}
+}
+
+func TestOnSetNotCalledWhenPersistFails(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	called := false
+	db, _ := smalldb.Open[flaky](file, smalldb.WithOnSet(func(string, flaky) { called = true }))
+	defer db.Close()
+
+	if err := db.Set("a", flaky{Value: "bad", Fail: true}); err == nil {
+		t.Fatalf("Expected Set to fail")
+	}
+	if called {
+		t.Fatalf("Expected OnSet not to be called for a failed write")
+	}
+}
+
+func TestBeforeCommitCanVeto(t *testing.T) {
+	errVeto := errors.New("veto")
+	db := smalldb.OpenMemory[User](smalldb.WithBeforeCommit(func(tx *smalldb.Tx[User]) error {
+		if tx.Has("user:banned") {
+			return errVeto
+		}
+		return nil
+	}))
+
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:banned", User{Name: "Mallory"})
+		return nil
+	})
+	if !errors.Is(err, errVeto) {
+		t.Fatalf("Expected veto error, got %v", err)
+	}
+	if db.Len() != 0 {
+		t.Fatalf("Expected nothing to be committed, got %v", db.GetAll())
+	}
+}
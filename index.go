@@ -0,0 +1,133 @@
+package smalldb
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// index maps the values computed by fn to the keys that produced them.
+type index[T any] struct {
+	fn      func(value T) string
+	buckets map[string]map[string]struct{}
+	byKey   map[string]string
+}
+
+// Index declares a secondary index called name over the value computed by fn,
+// so ByIndex can find entries without scanning the database. The index is built
+// from the current data and kept up to date by every write, transaction and
+// reload. Declaring an index with an existing name replaces it. Indexes live in
+// memory only, so declare them again after each Open.
+func (db *DB[T]) Index(name string, fn func(value T) string) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	idx := &index[T]{fn: fn}
+	idx.rebuild(db.data)
+	if db.indexes == nil {
+		db.indexes = make(map[string]*index[T])
+	}
+	db.indexes[name] = idx
+}
+
+// ByIndex returns the values whose index name maps to value, in key order.
+// It panics if no index called name has been declared.
+func (db *DB[T]) ByIndex(name, value string) []T {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	idx, ok := db.indexes[name]
+	if !ok {
+		panic(fmt.Sprintf("smalldb: unknown index %q", name))
+	}
+
+	result := []T{}
+	if db.closed {
+		return result
+	}
+
+	keys := make([]string, 0, len(idx.buckets[value]))
+	for k := range idx.buckets[value] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	now := time.Now()
+	for _, k := range keys {
+		v, exists := db.data[k]
+		if !exists || db.isExpired(k, now) {
+			continue
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// updateIndexes applies events to every index.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) updateIndexes(events []Event[T]) {
+	for _, idx := range db.indexes {
+		for _, e := range events {
+			idx.remove(e.Key)
+			if e.Op == OpSet {
+				idx.add(e.Key, e.Value)
+			}
+		}
+	}
+}
+
+// reindex updates every index for keys from their current values, such as
+// after a failed write has been undone.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) reindex(keys ...string) {
+	for _, idx := range db.indexes {
+		for _, k := range keys {
+			idx.remove(k)
+			if v, ok := db.data[k]; ok {
+				idx.add(k, v)
+			}
+		}
+	}
+}
+
+// rebuildIndexes recomputes every index from the current data.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) rebuildIndexes() {
+	for _, idx := range db.indexes {
+		idx.rebuild(db.data)
+	}
+}
+
+// rebuild recomputes the index from data.
+func (idx *index[T]) rebuild(data map[string]T) {
+	idx.buckets = make(map[string]map[string]struct{})
+	idx.byKey = make(map[string]string, len(data))
+	for k, v := range data {
+		idx.add(k, v)
+	}
+}
+
+// add records key under the indexed value of v.
+func (idx *index[T]) add(key string, v T) {
+	value := idx.fn(v)
+	bucket, ok := idx.buckets[value]
+	if !ok {
+		bucket = make(map[string]struct{})
+		idx.buckets[value] = bucket
+	}
+	bucket[key] = struct{}{}
+	idx.byKey[key] = value
+}
+
+// remove forgets key, if it is indexed.
+func (idx *index[T]) remove(key string) {
+	value, ok := idx.byKey[key]
+	if !ok {
+		return
+	}
+	delete(idx.buckets[value], key)
+	if len(idx.buckets[value]) == 0 {
+		delete(idx.buckets, value)
+	}
+	delete(idx.byKey, key)
+}
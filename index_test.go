@@ -0,0 +1,94 @@
+package smalldb_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestIndex(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	db.Index("name", func(u User) string { return u.Name })
+	if got := db.ByIndex("name", "Alice"); len(got) != 1 || got[0].Age != 30 {
+		t.Fatalf("Expected index to include existing data, got %v", got)
+	}
+
+	_ = db.Set("user:2", User{Name: "Alice", Age: 25})
+	_ = db.Set("user:1", User{Name: "Alicia", Age: 30})
+	want := []User{{Name: "Alice", Age: 25}}
+	if got := db.ByIndex("name", "Alice"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Delete("user:2")
+		tx.Set("user:3", User{Name: "Alicia", Age: 40})
+		return nil
+	})
+	if got := db.ByIndex("name", "Alice"); len(got) != 0 {
+		t.Fatalf("Expected deleted entry to leave the index, got %v", got)
+	}
+	if got := db.ByIndex("name", "Alicia"); len(got) != 2 {
+		t.Fatalf("Expected two entries named Alicia, got %v", got)
+	}
+}
+
+func TestIndexFollowsFailedPersist(t *testing.T) {
+	byName := func(u User) string { return u.Name }
+
+	disk := &fullDisk{}
+	db, _ := smalldb.Open[User]("db.json", smalldb.WithStorage(disk))
+	defer db.Close()
+	db.Index("name", byName)
+	_ = db.Set("a", User{Name: "x"})
+
+	disk.full = true
+	if err := db.Set("a", User{Name: "y"}); err == nil {
+		t.Fatalf("Expected the write to fail")
+	}
+	if got := db.ByIndex("name", "y"); len(got) != 1 {
+		t.Fatalf("Expected the index to follow the value kept in memory, got %v", got)
+	}
+	if got := db.ByIndex("name", "x"); len(got) != 0 {
+		t.Fatalf("Expected the old value to leave the index, got %v", got)
+	}
+	if err := db.SetMany(map[string]User{"a": {Name: "z"}, "b": {Name: "z"}}); err == nil {
+		t.Fatalf("Expected the batch to fail")
+	}
+	if got := db.ByIndex("name", "z"); len(got) != 0 {
+		t.Fatalf("Expected the rolled back batch to leave the index, got %v", got)
+	}
+
+	strict, _ := smalldb.Open[User]("db.json", smalldb.WithStorage(&fullDisk{full: true}), smalldb.WithRollbackOnPersistError())
+	defer strict.Close()
+	strict.Index("name", byName)
+	_ = strict.Replace(map[string]User{"a": {Name: "x"}})
+	if got := strict.ByIndex("name", "x"); len(got) != 0 {
+		t.Fatalf("Expected the rolled back write to leave the index, got %v", got)
+	}
+	_ = strict.Set("a", User{Name: "x"})
+	if got := strict.ByIndex("name", "x"); len(got) != 0 {
+		t.Fatalf("Expected the rolled back write to leave the index, got %v", got)
+	}
+}
+
+func TestIndexForgetsExpiredKeys(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	defer db.Close()
+	db.Index("name", func(u User) string { return u.Name })
+
+	_ = db.SetWithTTL("user:1", User{Name: "Alice"}, time.Millisecond)
+	_ = db.SetWithTTL("user:2", User{Name: "Alice"}, time.Millisecond)
+	_ = db.Set("user:3", User{Name: "Bob"})
+	time.Sleep(5 * time.Millisecond)
+	db.Get("user:1")
+	_ = db.Update("user:3", func(u User, _ bool) (User, error) { return u, nil })
+
+	if n := smalldb.IndexLen(db, "name"); n != 1 {
+		t.Fatalf("Expected only the live key to be indexed, got %d", n)
+	}
+}
@@ -0,0 +1,60 @@
+package smalldb
+
+import "time"
+
+// WithInvariant registers fn to check a constraint spanning the whole dataset,
+// such as "at most one user is an admin". fn receives every entry as it would
+// be after a write, before anything is changed, and returning an error rejects
+// the write: Set, Delete, SetMany and the other write methods return it
+// unchanged, as does Transaction, which checks its working data at commit.
+// fn must not modify the map or call back into the database. Entries removed by
+// expiry or WithMaxEntries are not checked. Invariants run in the order they
+// were registered. T must match the value type of the database being opened.
+func WithInvariant[T any](fn func(all map[string]T) error) Option {
+	return func(c *config) {
+		c.invariants = append(c.invariants, fn)
+	}
+}
+
+// newInvariants extracts the invariants from cfg.
+func newInvariants[T any](cfg *config) []func(map[string]T) error {
+	var invariants []func(map[string]T) error
+	for _, fn := range cfg.invariants {
+		invariants = append(invariants, typedOption[func(map[string]T) error](fn, "WithInvariant"))
+	}
+	return invariants
+}
+
+// checkInvariants runs every invariant against all, returning the first error.
+func (db *DB[T]) checkInvariants(all map[string]T) error {
+	for _, fn := range db.invariants {
+		if err := fn(all); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChange runs the invariants against the live data as it would be after
+// storing set and removing deleted.
+// The caller must hold db.mu.
+func (db *DB[T]) checkChange(set map[string]T, deleted ...string) error {
+	if len(db.invariants) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	prospective := make(map[string]T, len(db.data)+len(set))
+	for k, v := range db.data {
+		if !db.isExpired(k, now) {
+			prospective[k] = v
+		}
+	}
+	for _, k := range deleted {
+		delete(prospective, k)
+	}
+	for k, v := range set {
+		prospective[k] = v
+	}
+	return db.checkInvariants(prospective)
+}
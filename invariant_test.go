@@ -0,0 +1,57 @@
+package smalldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+type member struct {
+	Name  string
+	Admin bool
+}
+
+var errTwoAdmins = errors.New("at most one admin allowed")
+
+func singleAdmin(all map[string]member) error {
+	admins := 0
+	for _, m := range all {
+		if m.Admin {
+			admins++
+		}
+	}
+	if admins > 1 {
+		return errTwoAdmins
+	}
+	return nil
+}
+
+func TestInvariantRejectsWrites(t *testing.T) {
+	db := smalldb.OpenMemory[member](smalldb.WithInvariant(singleAdmin))
+	_ = db.Set("alice", member{Name: "Alice", Admin: true})
+
+	if err := db.Set("bob", member{Name: "Bob", Admin: true}); !errors.Is(err, errTwoAdmins) {
+		t.Fatalf("Expected invariant error from Set, got %v", err)
+	}
+	if db.Has("bob") {
+		t.Fatalf("Expected the rejected write not to be applied")
+	}
+
+	err := db.Transaction(func(tx *smalldb.Tx[member]) error {
+		tx.Set("bob", member{Name: "Bob", Admin: true})
+		return nil
+	})
+	if !errors.Is(err, errTwoAdmins) {
+		t.Fatalf("Expected invariant error from Transaction, got %v", err)
+	}
+
+	err = db.Transaction(func(tx *smalldb.Tx[member]) error {
+		tx.Set("alice", member{Name: "Alice"})
+		tx.Set("bob", member{Name: "Bob", Admin: true})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected handing over admin in one transaction to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package smalldb
+
+import (
+	"iter"
+	"time"
+)
+
+// All returns an iterator over every entry in the database without copying the dataset:
+//
+//	for key, value := range db.All() {
+//		...
+//	}
+//
+// The read lock is held for the whole loop, so the loop body must not write to
+// the database (that deadlocks) and long-running loops delay writers.
+// Iteration order is unspecified.
+func (db *DB[T]) All() iter.Seq2[string, T] {
+	return func(yield func(string, T) bool) {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+
+		if db.closed {
+			return
+		}
+
+		now := time.Now()
+		for k, v := range db.data {
+			if db.isExpired(k, now) {
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Each calls fn for every entry in ascending key order, stopping at and
+// returning the first error fn returns. Unlike All, fn runs on a snapshot taken
+// under the read lock, so it may safely write to the database; such writes are
+// not seen by the remaining calls.
+func (db *DB[T]) Each(fn func(key string, value T) error) error {
+	db.mu.RLock()
+	if db.closed {
+		db.mu.RUnlock()
+		return ErrClosed
+	}
+	keys := db.sortedKeys()
+	entries := make([]KeyValue[T], len(keys))
+	for i, k := range keys {
+		entries[i] = KeyValue[T]{Key: k, Value: db.data[k]}
+	}
+	db.mu.RUnlock()
+
+	for _, e := range entries {
+		if err := fn(e.Key, e.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EachSnapshot calls fn for every key in ascending key order, stopping at and
+// returning the first error fn returns. Only the keys are copied up front; each
+// value is looked up just before fn is called for it, and keys deleted or
+// expired by then are skipped. No lock is held while fn runs, so slow callbacks
+// do not hold up writers, but the entries seen form a fuzzy rather than
+// consistent snapshot: changes made during the iteration may or may not be seen,
+// and keys added since it began are not visited. It returns ErrClosed if the
+// database is closed before the iteration completes.
+func (db *DB[T]) EachSnapshot(fn func(key string, value T) error) error {
+	db.mu.RLock()
+	if db.closed {
+		db.mu.RUnlock()
+		return ErrClosed
+	}
+	keys := db.sortedKeys()
+	db.mu.RUnlock()
+
+	for _, k := range keys {
+		db.mu.RLock()
+		if db.closed {
+			db.mu.RUnlock()
+			return ErrClosed
+		}
+		value, exists := db.data[k]
+		live := exists && !db.isExpired(k, time.Now())
+		db.mu.RUnlock()
+
+		if !live {
+			continue
+		}
+		if err := fn(k, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
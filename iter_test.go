@@ -0,0 +1,89 @@
+package smalldb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestAll(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+		"user:3": {Name: "Charlie", Age: 28},
+	})
+
+	total := 0
+	for _, user := range db.All() {
+		total += user.Age
+	}
+	if total != 83 {
+		t.Fatalf("Expected ages to sum to 83, got %d", total)
+	}
+
+	seen := 0
+	for range db.All() {
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Fatalf("Expected early break to stop iteration, saw %d", seen)
+	}
+
+	// The lock is released after breaking out of the loop.
+	if err := db.Set("user:4", User{Name: "Dave"}); err != nil {
+		t.Fatalf("Failed to set after iteration: %v", err)
+	}
+}
+
+func TestEachVisitsInKeyOrder(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"c": 3, "a": 1, "b": 2})
+
+	if keys := db.SortedKeys(); !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Fatalf("Expected sorted keys, got %v", keys)
+	}
+
+	errStop := errors.New("stop")
+	var visited []string
+	err := db.Each(func(key string, value int) error {
+		visited = append(visited, key)
+		if key == "b" {
+			return errStop
+		}
+		return db.Set(key, value*10)
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Expected stop error, got %v", err)
+	}
+	if !reflect.DeepEqual(visited, []string{"a", "b"}) {
+		t.Fatalf("Expected to visit a and b, got %v", visited)
+	}
+	if value, _ := db.Get("a"); value != 10 {
+		t.Fatalf("Expected write from Each to succeed, got %d", value)
+	}
+}
+
+func TestEachSnapshotSeesLatestValues(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	got := make(map[string]int)
+	err := db.EachSnapshot(func(key string, value int) error {
+		got[key] = value
+		if key == "a" {
+			_ = db.Delete("b")
+			_ = db.Set("c", 30)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachSnapshot failed: %v", err)
+	}
+	if want := map[string]int{"a": 1, "c": 30}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,100 @@
+package smalldb
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// KeyEncoder converts typed keys to and from the string keys stored in the file.
+// Decode must accept every string Encode returns and give back an equal key.
+type KeyEncoder[K any] interface {
+	Encode(key K) string
+	Decode(s string) (K, error)
+}
+
+// Int64Keys encodes int64 keys in base 10.
+type Int64Keys struct{}
+
+// Encode formats key in base 10.
+func (Int64Keys) Encode(key int64) string {
+	return strconv.FormatInt(key, 10)
+}
+
+// Decode parses s as a base 10 int64.
+func (Int64Keys) Decode(s string) (int64, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// Keyed is a view of a database whose keys have type K rather than string.
+// Keys are converted with a KeyEncoder on the way in and out, so the file still
+// holds string keys and the database can be used directly alongside the view.
+// Use DB for operations the view does not provide.
+type Keyed[K comparable, T any] struct {
+	db  *DB[T]
+	enc KeyEncoder[K]
+}
+
+// WithKeys returns a view of db that uses keys of type K, converted with enc.
+func WithKeys[K comparable, T any](db *DB[T], enc KeyEncoder[K]) *Keyed[K, T] {
+	return &Keyed[K, T]{db: db, enc: enc}
+}
+
+// DB returns the underlying database.
+func (k *Keyed[K, T]) DB() *DB[T] {
+	return k.db
+}
+
+// Get retrieves the value associated with the given key.
+func (k *Keyed[K, T]) Get(key K) (T, bool) {
+	return k.db.Get(k.enc.Encode(key))
+}
+
+// GetDefault returns the value associated with the given key, or def if it is missing.
+func (k *Keyed[K, T]) GetDefault(key K, def T) T {
+	return k.db.GetDefault(k.enc.Encode(key), def)
+}
+
+// Has reports whether the given key exists.
+func (k *Keyed[K, T]) Has(key K) bool {
+	return k.db.Has(k.enc.Encode(key))
+}
+
+// Set sets the value for the given key.
+func (k *Keyed[K, T]) Set(key K, value T) error {
+	return k.db.Set(k.enc.Encode(key), value)
+}
+
+// Delete removes the value associated with the given key.
+func (k *Keyed[K, T]) Delete(key K) error {
+	return k.db.Delete(k.enc.Encode(key))
+}
+
+// Keys returns all keys in no particular order. It returns an error naming the
+// first stored key that cannot be decoded.
+func (k *Keyed[K, T]) Keys() ([]K, error) {
+	raw := k.db.Keys()
+	keys := make([]K, len(raw))
+	for i, s := range raw {
+		key, err := k.enc.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("smalldb: decoding key %q: %w", s, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// GetAll returns a copy of all key-value pairs. It returns an error naming the
+// first stored key that cannot be decoded.
+func (k *Keyed[K, T]) GetAll() (map[K]T, error) {
+	raw := k.db.GetAll()
+	all := make(map[K]T, len(raw))
+	for s, v := range raw {
+		key, err := k.enc.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("smalldb: decoding key %q: %w", s, err)
+		}
+		all[key] = v
+	}
+	return all, nil
+}
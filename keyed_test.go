@@ -0,0 +1,34 @@
+package smalldb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestKeyedView(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	users := smalldb.WithKeys(db, smalldb.Int64Keys{})
+
+	_ = users.Set(42, User{Name: "Alice", Age: 30})
+	if user, ok := users.Get(42); !ok || user.Name != "Alice" {
+		t.Fatalf("Expected Alice, got %v (found: %v)", user, ok)
+	}
+	if !db.Has("42") {
+		t.Fatal("Expected the key to be stored as a string")
+	}
+
+	all, err := users.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	if !reflect.DeepEqual(all, map[int64]User{42: {Name: "Alice", Age: 30}}) {
+		t.Fatalf("Unexpected entries: %v", all)
+	}
+
+	_ = db.Set("not-a-number", User{Name: "Bob"})
+	if _, err := users.Keys(); err == nil {
+		t.Fatal("Expected an error for a key that cannot be decoded")
+	}
+}
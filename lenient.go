@@ -0,0 +1,40 @@
+package smalldb
+
+import "encoding/json"
+
+// WithLenientLoad lets Open and Reload skip values that cannot be decoded
+// instead of failing, so one damaged record does not make the rest of the file
+// unreachable. Skipped values are listed by LoadReport. They are not kept in
+// memory, so the next write removes them from the file; copy them out of the
+// report first if they need repairing. It requires the default JSON codec and format.
+func WithLenientLoad() Option {
+	return func(c *config) {
+		c.lenient = true
+	}
+}
+
+// QuarantinedRecord is a stored value that was skipped because it could not be loaded.
+type QuarantinedRecord struct {
+	Key string
+	Raw json.RawMessage // The value exactly as stored in the file.
+	Err error
+}
+
+// LoadReport describes the outcome of the most recent load of the file.
+type LoadReport struct {
+	Quarantined []QuarantinedRecord // Skipped values in key order.
+}
+
+// LoadReport returns what was skipped when the file was last loaded by Open or
+// Reload. It is always empty without WithLenientLoad.
+func (db *DB[T]) LoadReport() LoadReport {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.report
+}
+
+// quarantine records that the value stored under key was skipped.
+func (env *envelope[T]) quarantine(key string, raw json.RawMessage, err error) {
+	env.quarantined = append(env.quarantined, QuarantinedRecord{Key: key, Raw: raw, Err: err})
+}
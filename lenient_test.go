@@ -0,0 +1,40 @@
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestLenientLoadQuarantinesBadRecords(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	content := `{"user:1": {"Name": "Alice", "Age": 30}, "user:2": {"Name": "Bob", "Age": "old"}}`
+	_ = os.WriteFile(file, []byte(content), 0644)
+
+	if _, err := smalldb.Open[User](file); !errors.Is(err, smalldb.ErrInvalidFormat) {
+		t.Fatalf("Expected a strict load to fail, got %v", err)
+	}
+
+	db, err := smalldb.Open[User](file, smalldb.WithLenientLoad())
+	if err != nil {
+		t.Fatalf("Expected a lenient load to succeed, got %v", err)
+	}
+	defer db.Close()
+
+	if db.Len() != 1 || !db.Has("user:1") {
+		t.Fatalf("Expected only the good record to load, got %v", db.GetAll())
+	}
+
+	report := db.LoadReport()
+	if len(report.Quarantined) != 1 {
+		t.Fatalf("Expected one quarantined record, got %+v", report)
+	}
+	record := report.Quarantined[0]
+	if record.Key != "user:2" || string(record.Raw) != `{"Name": "Bob", "Age": "old"}` || record.Err == nil {
+		t.Fatalf("Unexpected quarantined record: %+v", record)
+	}
+}
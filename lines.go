@@ -0,0 +1,100 @@
+package smalldb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Format selects the layout of the database file.
+type Format int
+
+const (
+	// JSON stores the whole database as a single document written with the
+	// configured codec. This is the default.
+	JSON Format = iota
+
+	// JSONLines stores one JSON record per line, each of the form
+	// {"key":...,"value":...}, plus "expires" for keys with a TTL.
+	// The file is easy to grep and tail, and a truncated final line left by a
+	// crash is skipped on load, and reported through WithLogger, instead of
+	// failing the whole file.
+	JSONLines
+)
+
+// WithFormat sets the layout of the database file. The default is JSON.
+// JSONLines always encodes values as JSON and ignores WithCodec and WithIndent.
+// The format must match the existing file; files are not converted between formats.
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// jsonLine is a single record of a JSONLines file.
+type jsonLine[T any] struct {
+	Key     string     `json:"key"`
+	Value   T          `json:"value"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// writeJSONLines writes env to w as one record per line in key order.
+func writeJSONLines[T any](w io.Writer, env *envelope[T]) error {
+	keys := make([]string, 0, len(env.Data))
+	for k := range env.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, k := range keys {
+		line := jsonLine[T]{Key: k, Value: env.Data[k]}
+		if expiry, ok := env.Expires[k]; ok {
+			line.Expires = &expiry
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// readJSONLines decodes the records in raw into env. A malformed final line,
+// as left by an interrupted write, is skipped and reported to logger if it is
+// not nil; malformed lines anywhere else are reported as errors.
+func readJSONLines[T any](raw []byte, env *envelope[T], logger *slog.Logger) error {
+	lines := bytes.Split(raw, []byte("\n"))
+	for len(lines) > 0 && len(bytes.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, text := range lines {
+		if len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		var line jsonLine[T]
+		if err := json.Unmarshal(text, &line); err != nil {
+			if i == len(lines)-1 {
+				if logger != nil {
+					logger.Warn("smalldb: skipped a truncated final line", "line", i+1, "error", err)
+				}
+				return nil
+			}
+			return fmt.Errorf("smalldb: line %d: %w", i+1, err)
+		}
+
+		env.Data[line.Key] = line.Value
+		if line.Expires != nil {
+			env.Expires[line.Key] = *line.Expires
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package smalldb_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestJSONLinesFormat(t *testing.T) {
+	file := "test_db.jsonl"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithFormat(smalldb.JSONLines))
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.SetWithTTL("session:abc", User{Name: "Alice"}, time.Hour)
+	_ = db.Close()
+
+	content, _ := os.ReadFile(file)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 || lines[1] != `{"key":"user:1","value":{"Name":"Alice","Age":30}}` {
+		t.Fatalf("Expected one record per line in key order, got %s", content)
+	}
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithFormat(smalldb.JSONLines))
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 3 {
+		t.Fatalf("Expected 3 entries after reopening, got %v", reopened.GetAll())
+	}
+}
+
+func TestJSONLinesSkipsTruncatedFinalLine(t *testing.T) {
+	file := "test_db.jsonl"
+	defer cleanup(file)
+
+	content := `{"key":"user:1","value":{"Name":"Alice","Age":30}}` + "\n" + `{"key":"user:2","val`
+	_ = os.WriteFile(file, []byte(content), 0644)
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	db, err := smalldb.Open[User](file, smalldb.WithFormat(smalldb.JSONLines), smalldb.WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Expected truncated final line to be skipped, got %v", err)
+	}
+	defer db.Close()
+	if db.Len() != 1 || !db.Has("user:1") {
+		t.Fatalf("Expected only user:1 to load, got %v", db.GetAll())
+	}
+	if logged := out.String(); !strings.Contains(logged, "smalldb: skipped a truncated final line") || !strings.Contains(logged, "line=2") {
+		t.Fatalf("Expected the skipped line to be logged, got %q", logged)
+	}
+}
+
+func TestJSONLinesRejectsCorruptMiddleLine(t *testing.T) {
+	file := "test_db.jsonl"
+	defer cleanup(file)
+
+	content := "garbage\n" + `{"key":"user:1","value":{"Name":"Alice","Age":30}}` + "\n"
+	_ = os.WriteFile(file, []byte(content), 0644)
+
+	if _, err := smalldb.Open[User](file, smalldb.WithFormat(smalldb.JSONLines)); err == nil {
+		t.Fatalf("Expected a corrupt line before valid records to fail")
+	}
+}
@@ -0,0 +1,50 @@
+package smalldb
+
+import "os"
+
+// fileLock is an OS-level advisory lock held on a sidecar file next to the database.
+// The data file itself cannot be locked because atomic writes replace it on every persist.
+type fileLock struct {
+	file *os.File
+}
+
+// acquireLock takes an exclusive lock on path+".lock", or a shared one if shared is set.
+// It returns ErrLocked if a conflicting lock is held elsewhere. A shared lock
+// never creates the lock file: if it is missing or cannot be opened for lack of
+// permission the lock is skipped, returning a nil lock, so read-only databases
+// leave nothing behind and can be opened from read-only locations.
+func acquireLock(path string, shared bool, mode os.FileMode) (*fileLock, error) {
+	var file *os.File
+	var err error
+	if shared {
+		file, err = os.Open(path + ".lock")
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			return nil, nil
+		}
+	} else {
+		file, err = os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(file, shared); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fileLock{file: file}, nil
+}
+
+// release drops the lock and closes the lock file.
+func (l *fileLock) release() error {
+	if l == nil {
+		return nil
+	}
+
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package smalldb
+
+import "os"
+
+// lockFile is a no-op on platforms without advisory file locking.
+func lockFile(file *os.File, shared bool) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without advisory file locking.
+func unlockFile(file *os.File) error {
+	return nil
+}
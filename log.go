@@ -0,0 +1,51 @@
+package smalldb
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// defaultSlowThreshold is how long a persist may take before it is logged as slow.
+const defaultSlowThreshold = 100 * time.Millisecond
+
+// WithLogger sends the database's diagnostics to logger. Persists that take
+// longer than the slow threshold are logged at warning level with the number
+// of keys and bytes written; every persist, and the time taken by Open,
+// Reload and Compact, is logged at debug level. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithSlowThreshold sets how long a persist may take before WithLogger reports
+// it as slow. The default is 100ms.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.slowThreshold = d
+	}
+}
+
+// logTiming logs at debug level that op, which began at start, has finished.
+func (c *config) logTiming(op, path string, start time.Time, attrs ...any) {
+	if c.logger == nil || !c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	attrs = append([]any{"path", path, "duration", time.Since(start)}, attrs...)
+	c.logger.Debug("smalldb: "+op, attrs...)
+}
+
+// logPersist logs a persist of keys entries and written bytes that began at
+// start, as a warning if it took longer than the slow threshold.
+func (c *config) logPersist(path string, start time.Time, keys int, written int64) {
+	if c.logger == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed > c.slowThreshold {
+		c.logger.Warn("smalldb: slow persist", "path", path, "duration", elapsed, "keys", keys, "bytes", written)
+		return
+	}
+	c.logTiming("persist", path, start, "keys", keys, "bytes", written)
+}
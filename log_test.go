@@ -0,0 +1,32 @@
+package smalldb_test
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestWithLoggerReportsSlowPersists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	db, err := smalldb.Open[User](file, smalldb.WithLogger(logger), smalldb.WithSlowThreshold(-1))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if !strings.Contains(out.String(), "smalldb: open") {
+		t.Fatalf("Expected Open to be logged, got %q", out.String())
+	}
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	logged := out.String()
+	if !strings.Contains(logged, "smalldb: slow persist") || !strings.Contains(logged, "keys=1") || !strings.Contains(logged, "bytes=") {
+		t.Fatalf("Expected a slow persist with keys and bytes, got %q", logged)
+	}
+}
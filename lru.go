@@ -0,0 +1,169 @@
+package smalldb
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// WithMaxEntries caps the database at n entries, turning it into a bounded
+// cache. Get and every write mark a key as recently used, and when a write
+// would leave more than n entries the least recently used ones are evicted.
+// Evictions are persisted with the write that caused them and reported to
+// subscribers and OnDelete hooks as deletions.
+func WithMaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// WithOnEvict registers fn to be called for each entry evicted by WithMaxEntries,
+// after the eviction has been persisted.
+// T must match the value type of the database being opened.
+func WithOnEvict[T any](fn func(key string, value T)) Option {
+	return func(c *config) {
+		c.onEvict = fn
+	}
+}
+
+// lru tracks the order in which keys were last used. It has its own mutex so
+// that Get can record accesses while holding only the read lock.
+type lru struct {
+	mu    sync.Mutex
+	order *list.List // Most recently used at the front.
+	elems map[string]*list.Element
+}
+
+// reset forgets the access order and tracks keys instead, treating earlier
+// keys as more recently used.
+func (l *lru) reset(keys []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.order = list.New()
+	l.elems = make(map[string]*list.Element, len(keys))
+	for _, k := range keys {
+		l.elems[k] = l.order.PushBack(k)
+	}
+}
+
+// touch marks key as the most recently used.
+func (l *lru) touch(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.elems[key]; ok {
+		l.order.MoveToFront(elem)
+		return
+	}
+	l.elems[key] = l.order.PushFront(key)
+}
+
+// pushOldest tracks key as the least recently used.
+func (l *lru) pushOldest(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.elems[key]; ok {
+		l.order.MoveToBack(elem)
+		return
+	}
+	l.elems[key] = l.order.PushBack(key)
+}
+
+// remove stops tracking key.
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.elems[key]; ok {
+		l.order.Remove(elem)
+		delete(l.elems, key)
+	}
+}
+
+// popOldest stops tracking the least recently used key and returns it.
+func (l *lru) popOldest() (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem := l.order.Back()
+	if elem == nil {
+		return "", false
+	}
+	key := l.order.Remove(elem).(string)
+	delete(l.elems, key)
+	return key, true
+}
+
+// eviction is an entry removed to honour WithMaxEntries.
+type eviction[T any] struct {
+	key     string
+	value   T
+	expires time.Time
+	hasTTL  bool
+}
+
+// trackAccess marks key as recently used if the database is bounded.
+func (db *DB[T]) trackAccess(key string) {
+	if db.lru != nil {
+		db.lru.touch(key)
+	}
+}
+
+// evictOverflow records the keys written by events as recently used and then
+// evicts least recently used entries until the database is within its cap.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) evictOverflow(events []Event[T]) []eviction[T] {
+	if db.lru == nil {
+		return nil
+	}
+
+	for _, e := range events {
+		if e.Op == OpSet {
+			db.lru.touch(e.Key)
+		} else {
+			db.lru.remove(e.Key)
+		}
+	}
+
+	var evicted []eviction[T]
+	for len(db.data) > db.cfg.maxEntries {
+		key, ok := db.lru.popOldest()
+		if !ok {
+			break
+		}
+		value, exists := db.data[key]
+		if !exists {
+			continue // Removed without an event, such as by expiry.
+		}
+
+		e := eviction[T]{key: key, value: value}
+		e.expires, e.hasTTL = db.expires[key]
+		evicted = append(evicted, e)
+		delete(db.data, key)
+		delete(db.expires, key)
+	}
+	return evicted
+}
+
+// restoreEvictions puts back entries evicted by a write that failed to persist.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) restoreEvictions(evicted []eviction[T]) {
+	for _, e := range evicted {
+		db.data[e.key] = e.value
+		if e.hasTTL {
+			db.expires[e.key] = e.expires
+		}
+		db.lru.pushOldest(e.key)
+		db.reindex(e.key)
+	}
+}
+
+// resetLRU tracks the current keys in sorted order, as no access order is known.
+// The caller must hold db.mu for writing or have exclusive access to db.
+func (db *DB[T]) resetLRU() {
+	if db.lru != nil {
+		db.lru.reset(db.sortedKeys())
+	}
+}
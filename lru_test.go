@@ -0,0 +1,59 @@
+package smalldb_test
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestMaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	var evicted []string
+	db, _ := smalldb.Open[int](file,
+		smalldb.WithMaxEntries(2),
+		smalldb.WithOnEvict(func(key string, _ int) { evicted = append(evicted, key) }),
+	)
+	defer db.Close()
+
+	_ = db.Set("a", 1)
+	_ = db.Set("b", 2)
+	db.Get("a") // b is now the least recently used.
+	_ = db.Set("c", 3)
+
+	if !reflect.DeepEqual(evicted, []string{"b"}) {
+		t.Fatalf("Expected b to be evicted, got %v", evicted)
+	}
+
+	_ = db.Close()
+	reopened, _ := smalldb.Open[int](file)
+	defer reopened.Close()
+
+	keys := reopened.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"a", "c"}) {
+		t.Fatalf("Expected the eviction to be persisted, got %v", keys)
+	}
+}
+
+func TestMaxEntriesForgetsExpiredKeys(t *testing.T) {
+	db := smalldb.OpenMemory[int](smalldb.WithMaxEntries(100))
+	defer db.Close()
+
+	for i := range 50 {
+		_ = db.SetWithTTL(fmt.Sprintf("temp:%d", i), i, time.Millisecond)
+	}
+	_ = db.Set("kept", 1)
+	time.Sleep(5 * time.Millisecond)
+	db.Get("temp:0")
+	_ = db.Update("other", func(int, bool) (int, error) { return 2, nil })
+
+	if n := smalldb.LRULen(db); n != 2 {
+		t.Fatalf("Expected only the live keys to be tracked, got %d", n)
+	}
+}
@@ -0,0 +1,46 @@
+package smalldb
+
+import "time"
+
+// Merge copies every entry of other into db and persists once.
+// When a key exists in both, conflict is called with db's value and other's
+// value and its result is stored; a nil conflict lets other's value win.
+// Merged keys carry no TTL, as with SetMany. other is read under its own
+// read lock before db is locked, so concurrent merges in opposite directions
+// cannot deadlock. Either every entry is merged or, if persisting fails, none are.
+func (db *DB[T]) Merge(other *DB[T], conflict func(key string, a, b T) T) error {
+	if other == db {
+		return nil
+	}
+
+	other.mu.RLock()
+	if other.closed {
+		other.mu.RUnlock()
+		return ErrClosed
+	}
+	now := time.Now()
+	incoming := make(map[string]T, len(other.data))
+	for k, v := range other.data {
+		if !other.isExpired(k, now) {
+			incoming[k] = v
+		}
+	}
+	other.mu.RUnlock()
+
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	db.purgeExpired(time.Now())
+	if conflict != nil {
+		for k, b := range incoming {
+			if a, exists := db.data[k]; exists {
+				incoming[k] = conflict(k, a, b)
+			}
+		}
+	}
+	return db.setMany(incoming)
+}
@@ -0,0 +1,41 @@
+package smalldb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestMerge(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"a": 1, "b": 2})
+
+	other := smalldb.OpenMemory[int]()
+	_ = other.SetMany(map[string]int{"b": 20, "c": 30})
+
+	err := db.Merge(other, func(_ string, a, b int) int { return a + b })
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+
+	want := map[string]int{"a": 1, "b": 22, "c": 30}
+	if got := db.GetAll(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestMergeDefaultsToOtherWins(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.Set("a", 1)
+
+	other := smalldb.OpenMemory[int]()
+	_ = other.Set("a", 2)
+
+	if err := db.Merge(other, nil); err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if value, _ := db.Get("a"); value != 2 {
+		t.Fatalf("Expected other's value to win, got %d", value)
+	}
+}
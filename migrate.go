@@ -0,0 +1,102 @@
+package smalldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Migration upgrades a single stored value by one schema version.
+type Migration func(raw json.RawMessage) (json.RawMessage, error)
+
+// WithSchemaVersion records version in the database file and upgrades files
+// written with an older version on Open. Each value is passed through the
+// migrations registered with WithMigration for every version from the file's
+// version up to this one, in order, before being decoded into T. Files without
+// a version are version 0, and versions with no registered migration are
+// assumed to be compatible. Opening a file with a newer version fails.
+// It requires the default JSON codec and format.
+func WithSchemaVersion(version int) Option {
+	return func(c *config) {
+		c.schemaVersion = version
+	}
+}
+
+// WithMigration registers fn to upgrade values from schema version from to from+1.
+// See WithSchemaVersion.
+func WithMigration(from int, fn Migration) Option {
+	return func(c *config) {
+		if c.migrations == nil {
+			c.migrations = make(map[int]Migration)
+		}
+		c.migrations[from] = fn
+	}
+}
+
+// decodeEach decodes raw into env one value at a time, first decompressing
+// values stored by WithValueCompression and migrating every value from the
+// file's schema version to the configured one. With
+// WithLenientLoad, values that fail to migrate or decode are quarantined in
+// env instead of failing the load.
+func decodeEach[T any](raw []byte, env *envelope[T], cfg *config) (*envelope[T], error) {
+	var file envelope[json.RawMessage]
+	if err := json.Unmarshal(raw, &file); err != nil || file.Format != envelopeFormat {
+		file = envelope[json.RawMessage]{}
+		if err := json.Unmarshal(raw, &file.Data); err != nil {
+			return nil, diagnoseJSON[T](raw, err)
+		}
+	}
+
+	if file.Version > cfg.schemaVersion {
+		return nil, fmt.Errorf("smalldb: file schema version %d is newer than supported version %d", file.Version, cfg.schemaVersion)
+	}
+
+	keys := make([]string, 0, len(file.Data))
+	for k := range file.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		decoded, err := decodeValue[T](k, file.Data[k], file.Version, cfg)
+		if err != nil {
+			if !cfg.lenient {
+				return nil, err
+			}
+			env.quarantine(k, file.Data[k], err)
+			continue
+		}
+		env.Data[k] = decoded
+	}
+	if file.Expires != nil {
+		env.Expires = file.Expires
+	}
+	return env, nil
+}
+
+// decodeValue decodes the value stored under key by a file of the given schema
+// version, decompressing it if WithValueCompression stored it compressed and
+// migrating it to the configured schema version first.
+func decodeValue[T any](key string, raw json.RawMessage, version int, cfg *config) (T, error) {
+	var decoded T
+	value, err := decompressValue(raw)
+	if err != nil {
+		return decoded, fmt.Errorf("%w: compressed value of key %q: %v", ErrInvalidFormat, key, err)
+	}
+
+	for ; version < cfg.schemaVersion; version++ {
+		migrate, ok := cfg.migrations[version]
+		if !ok {
+			continue
+		}
+		if value, err = migrate(value); err != nil {
+			return decoded, fmt.Errorf("smalldb: migrating %q from schema version %d: %w", key, version, err)
+		}
+	}
+
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return decoded, fmt.Errorf("%w: value of key %q does not match %s: %v", ErrInvalidFormat, key, reflect.TypeFor[T](), err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,64 @@
+package smalldb_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestSchemaMigration(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	_ = os.WriteFile(file, []byte(`{"user:1":{"FullName":"Alice","Age":30}}`), 0644)
+
+	renameField := func(raw json.RawMessage) (json.RawMessage, error) {
+		var old struct {
+			FullName string
+			Age      int
+		}
+		if err := json.Unmarshal(raw, &old); err != nil {
+			return nil, err
+		}
+		return json.Marshal(User{Name: old.FullName, Age: old.Age})
+	}
+
+	db, err := smalldb.Open[User](file,
+		smalldb.WithSchemaVersion(1),
+		smalldb.WithMigration(0, renameField),
+	)
+	if err != nil {
+		t.Fatalf("Failed to open with migration: %v", err)
+	}
+	if value, _ := db.Get("user:1"); value.Name != "Alice" || value.Age != 30 {
+		t.Fatalf("Expected migrated user, got %v", value)
+	}
+
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+	_ = db.Close()
+
+	content, _ := os.ReadFile(file)
+	if !strings.Contains(string(content), `"version": 1`) {
+		t.Fatalf("Expected the schema version to be stored, got %s", content)
+	}
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithSchemaVersion(1))
+	if err != nil {
+		t.Fatalf("Expected a current file to open without migrations, got %v", err)
+	}
+	_ = reopened.Close()
+}
+
+func TestSchemaVersionTooNew(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	_ = os.WriteFile(file, []byte(`{"$smalldb":1,"version":2,"data":{}}`), 0644)
+
+	if _, err := smalldb.Open[User](file, smalldb.WithSchemaVersion(1)); err == nil {
+		t.Fatalf("Expected a file from a newer schema version to be rejected")
+	}
+}
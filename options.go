@@ -0,0 +1,277 @@
+package smalldb
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// config holds the settings a database is opened with.
+type config struct {
+	fileMode os.FileMode
+	dirMode  os.FileMode
+	prefix   string
+	indent   string
+	codec    Codec
+	sweep    time.Duration
+	async    time.Duration
+	memory   bool
+	readOnly bool
+	noLock   bool
+	strict   bool
+	reload   time.Duration
+	backups  int
+	format   Format
+	wal      bool
+	storage  Storage
+	tempDir  string
+	sync     SyncMode
+
+	schemaVersion int
+	migrations    map[int]Migration
+
+	maxEntries      int
+	capacity        int
+	lenient         bool
+	rollbackOnError bool
+	marshalCheck    bool
+	cow             bool
+
+	compression      Compression
+	valueCompression int
+	encryptionKey    []byte
+	checksum         bool
+
+	eventBuffer int
+	changeLog   int
+
+	logger        *slog.Logger
+	slowThreshold time.Duration
+
+	snapshotInterval time.Duration
+	snapshotDir      string
+	snapshotKeep     int
+
+	validator    any
+	equality     any
+	keyValidator func(key string) error
+	onSet        any
+	onDelete     func(key string)
+	beforeCommit any
+	onEvict      any
+	onReload     any
+	invariants   []any
+}
+
+// Option configures a database when it is opened.
+type Option func(*config)
+
+// defaultConfig returns the settings used when no options are supplied.
+func defaultConfig() config {
+	return config{
+		fileMode: 0644,
+		dirMode:  0755,
+		indent:   "  ",
+		sweep:    time.Minute,
+
+		eventBuffer: 64,
+
+		slowThreshold: defaultSlowThreshold,
+	}
+}
+
+// newConfig applies opts on top of the defaults and fills in derived settings.
+func newConfig(opts []Option) config {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.codec == nil {
+		cfg.codec = JSONCodec{Prefix: cfg.prefix, Indent: cfg.indent}
+	}
+	return cfg
+}
+
+// validate reports settings that cannot be used to open a file-backed database.
+func (c *config) validate() error {
+	if c.encryptionKey != nil {
+		if err := validateKey(c.encryptionKey); err != nil {
+			return err
+		}
+		if c.wal {
+			return errors.New("smalldb: WithWAL cannot be combined with WithEncryption")
+		}
+	}
+	if c.storage != nil && (c.backups > 0 || c.wal || c.reload > 0) {
+		return errors.New("smalldb: WithStorage cannot be combined with WithBackups, WithWAL or WithAutoReload")
+	}
+	if c.schemaVersion > 0 || c.lenient || c.valueCompression > 0 {
+		if _, ok := c.codec.(JSONCodec); !ok || c.format != JSON {
+			return errors.New("smalldb: WithSchemaVersion, WithLenientLoad and WithValueCompression require the default JSON codec and format")
+		}
+	}
+	return nil
+}
+
+// typedOption converts a callback stored by a generic option back to its
+// concrete type. It panics if the option was declared for a different value
+// type than the database, since that is a programming error.
+func typedOption[F any](v any, name string) F {
+	var zero F
+	if v == nil {
+		return zero
+	}
+	f, ok := v.(F)
+	if !ok {
+		panic(fmt.Sprintf("smalldb: %s was given %T, which does not match the database value type", name, v))
+	}
+	return f
+}
+
+// WithFileMode sets the permissions used when creating the database file.
+// The default is 0644.
+func WithFileMode(mode os.FileMode) Option {
+	return func(c *config) {
+		c.fileMode = mode
+	}
+}
+
+// WithDirMode sets the permissions used when creating missing parent directories.
+// The default is 0755.
+func WithDirMode(mode os.FileMode) Option {
+	return func(c *config) {
+		c.dirMode = mode
+	}
+}
+
+// WithIndent sets the line prefix and indentation used when writing the JSON
+// file, as in json.MarshalIndent. The default is no prefix and two spaces.
+// Both must consist of whitespace for the file to remain readable.
+// It has no effect when a custom codec is set.
+func WithIndent(prefix, indent string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+		c.indent = indent
+	}
+}
+
+// WithCompactJSON writes the JSON file without any indentation or line breaks,
+// giving the smallest file and fastest writes at the cost of readability.
+// It is shorthand for WithIndent("", "").
+func WithCompactJSON() Option {
+	return WithIndent("", "")
+}
+
+// WithCodec sets the codec used to serialize the database file.
+// The default is JSON.
+func WithCodec(codec Codec) Option {
+	return func(c *config) {
+		c.codec = codec
+	}
+}
+
+// WithSweepInterval sets how often expired entries are swept from the database.
+// The default is one minute; zero disables the background sweep, leaving
+// expired entries to be removed lazily on access.
+func WithSweepInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.sweep = interval
+	}
+}
+
+// WithAsyncPersist buffers writes in memory and flushes them to disk at most
+// once per interval from a background goroutine, coalescing multiple writes.
+// This trades durability for throughput: writes made within the last interval
+// are lost if the process exits without calling Close. Close and Flush write
+// any pending changes immediately. By default every write is persisted synchronously.
+func WithAsyncPersist(interval time.Duration) Option {
+	return func(c *config) {
+		c.async = interval
+	}
+}
+
+// WithReadOnly opens the database for reading only. Open neither creates the
+// file nor its directory, and takes a shared lock instead of an exclusive one so
+// several read-only instances may share the file. The lock file is never
+// created: if no writer has left one behind, or it cannot be opened, the
+// database is opened unlocked.
+// Every write method returns ErrReadOnly without changing anything, as does a
+// Transaction that makes changes. Reads, View and Reload work as usual.
+func WithReadOnly() Option {
+	return func(c *config) {
+		c.readOnly = true
+	}
+}
+
+// WithEventBuffer sets how many events each subscriber channel buffers before
+// further events are dropped. The default is 64.
+func WithEventBuffer(size int) Option {
+	return func(c *config) {
+		c.eventBuffer = size
+	}
+}
+
+// WithCompression compresses the database file on disk.
+// Compressed and uncompressed files are both readable regardless of this setting,
+// so existing files can be switched over transparently on the next write.
+func WithCompression(compression Compression) Option {
+	return func(c *config) {
+		c.compression = compression
+	}
+}
+
+// WithEncryption encrypts the database file at rest with AES-GCM.
+// The key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+// Encryption is applied after compression. Opening a file with the wrong key
+// returns ErrDecryption.
+func WithEncryption(key []byte) Option {
+	return func(c *config) {
+		c.encryptionKey = key
+	}
+}
+
+// WithChecksum prefixes the database file with a small versioned header holding
+// a CRC-32 of its contents. The checksum is verified whenever a file carrying it
+// is opened, and a mismatch makes Open fail with ErrCorrupted.
+func WithChecksum() Option {
+	return func(c *config) {
+		c.checksum = true
+	}
+}
+
+// WithoutLocking skips the advisory file lock normally taken by Open.
+// Use it for read-mostly processes that share a file with a separate writer,
+// typically together with WithReadOnly and WithAutoReload.
+func WithoutLocking() Option {
+	return func(c *config) {
+		c.noLock = true
+	}
+}
+
+// WithAutoReload polls the database file at the given interval and reloads it
+// when another process modifies it. See Reload for what is discarded on reload,
+// and Transaction for how transactions avoid overwriting such changes.
+func WithAutoReload(interval time.Duration) Option {
+	return func(c *config) {
+		c.reload = interval
+	}
+}
+
+// WithInitialCapacity sizes the in-memory map for n entries when the database
+// is opened, so loading a large file of known size does not repeatedly grow
+// it. It is only a hint; the database still holds any number of entries.
+func WithInitialCapacity(n int) Option {
+	return func(c *config) {
+		c.capacity = n
+	}
+}
+
+// WithStrictMode makes Delete return ErrKeyNotFound for keys that do not exist
+// instead of silently succeeding.
+func WithStrictMode() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
@@ -0,0 +1,153 @@
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestOpenWithOptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nested", "db.json")
+
+	db, err := smalldb.Open[User](file,
+		smalldb.WithFileMode(0600),
+		smalldb.WithDirMode(0700),
+		smalldb.WithIndent("", "\t"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Set("user:1", User{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Failed to set data: %v", err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	dirInfo, err := os.Stat(filepath.Dir(file))
+	if err != nil {
+		t.Fatalf("Failed to stat dir: %v", err)
+	}
+	if dirInfo.Mode().Perm() != 0700 {
+		t.Fatalf("Expected dir mode 0700, got %v", dirInfo.Mode().Perm())
+	}
+
+	contents, _ := os.ReadFile(file)
+	if !strings.Contains(string(contents), "\n\t\"user:1\"") {
+		t.Fatalf("Expected tab indentation, got %q", contents)
+	}
+}
+
+func TestReadOnlyRefusesWrites(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "missing", "db.json")
+
+	db, err := smalldb.Open[User](file, smalldb.WithReadOnly())
+	if err != nil {
+		t.Fatalf("Failed to open read-only database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := os.Stat(filepath.Dir(file)); !os.IsNotExist(err) {
+		t.Fatalf("Expected read-only Open not to create directories, got %v", err)
+	}
+
+	if err := db.Set("user:1", User{Name: "Alice"}); !errors.Is(err, smalldb.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly from Set, got %v", err)
+	}
+	if err := db.Delete("user:1"); !errors.Is(err, smalldb.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly from Delete, got %v", err)
+	}
+	if err := db.Clear(); !errors.Is(err, smalldb.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly from Clear, got %v", err)
+	}
+	err = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:1", User{Name: "Alice"})
+		return nil
+	})
+	if !errors.Is(err, smalldb.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly from a writing Transaction, got %v", err)
+	}
+	if db.Has("user:1") {
+		t.Fatalf("Expected no write to reach memory")
+	}
+
+	err = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		_, _ = tx.Get("user:1")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected a reading Transaction to succeed, got %v", err)
+	}
+}
+
+func TestReadOnlyCreatesNoLockFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	writer, _ := smalldb.Open[User](file)
+	_ = writer.Set("user:1", User{Name: "Alice"})
+	_ = writer.Close()
+	if err := os.Remove(file + ".lock"); err != nil {
+		t.Fatalf("Failed to remove the writer's lock file: %v", err)
+	}
+
+	db, err := smalldb.Open[User](file, smalldb.WithReadOnly())
+	if err != nil {
+		t.Fatalf("Failed to open read-only database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := os.Stat(file + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("Expected read-only Open not to create a lock file, got %v", err)
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithCompactJSON())
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	content, _ := os.ReadFile(file)
+	if want := `{"user:1":{"Name":"Alice","Age":30}}`; string(content) != want {
+		t.Fatalf("Expected %s, got %s", want, content)
+	}
+}
+
+func TestInitialCapacity(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.SetWithTTL("user:2", User{Name: "Bob", Age: 25}, time.Hour)
+	_ = db.Close()
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithInitialCapacity(1000))
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 2 || !reopened.Has("user:2") {
+		t.Fatalf("Expected both entries to load, got %v", reopened.GetAll())
+	}
+
+	memory := smalldb.OpenMemory[User](smalldb.WithInitialCapacity(1000))
+	_ = memory.Set("user:1", User{Name: "Alice", Age: 30})
+	if memory.Len() != 1 {
+		t.Fatalf("Expected 1 entry, got %d", memory.Len())
+	}
+}
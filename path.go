@@ -0,0 +1,41 @@
+package smalldb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// preparePath checks that fp can hold a database and, unless it is opened
+// read-only, creates its directory and checks that files can be created there,
+// so a misconfigured path is reported by Open rather than by the first write.
+func preparePath(fp string, cfg *config) error {
+	if info, err := os.Stat(fp); err == nil && info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrPathIsDirectory, fp)
+	}
+	if cfg.readOnly {
+		return nil
+	}
+
+	dir := filepath.Dir(fp)
+	if err := os.MkdirAll(dir, cfg.dirMode); err != nil {
+		return permissionError(dir, err)
+	}
+	probe, err := os.CreateTemp(dir, ".smalldb-probe-*")
+	if err != nil {
+		return permissionError(dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// permissionError wraps err with ErrPermission and the path to check if it
+// reports a permission problem, and returns it unchanged otherwise.
+func permissionError(path string, err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("%w: check the permissions of %s: %w", ErrPermission, path, err)
+	}
+	return err
+}
@@ -0,0 +1,35 @@
+package smalldb_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestOpenDirectoryPath(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := smalldb.Open[User](dir); !errors.Is(err, smalldb.ErrPathIsDirectory) {
+		t.Fatalf("Expected ErrPathIsDirectory, got %v", err)
+	}
+}
+
+func TestOpenReadOnlyParent(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	dir := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(dir, 0555); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.Chmod(dir, 0755)
+
+	_, err := smalldb.Open[User](filepath.Join(dir, "db.json"), smalldb.WithoutLocking())
+	if !errors.Is(err, smalldb.ErrPermission) || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Expected ErrPermission, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package smalldb
+
+import "time"
+
+// Flush writes the current in-memory state to disk and syncs it, regardless of
+// any WithAsyncPersist interval. Use it at checkpoints where data must be durable.
+// In synchronous mode every write is already durable and Flush simply rewrites the file.
+func (db *DB[T]) Flush() error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	return db.flush()
+}
+
+// persist writes the in-memory data to disk, or marks it for the next
+// background flush when asynchronous persistence is enabled.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) persist() error {
+	if db.cfg.async > 0 {
+		db.dirty = true
+		return nil
+	}
+	return db.flush()
+}
+
+// commit evicts entries beyond WithMaxEntries, updates the indexes, persists
+// the current state, or with WithWAL logs the change, and once that succeeds
+// records it for Changes, runs the OnSet, OnDelete and OnEvict hooks and then
+// publishes events describing the change to subscribers. events must cover
+// every key the write changed; before, if not nil, holds the values from
+// before the write, and hooks and subscribers are not told about keys set to a
+// value WithEquality considers unchanged.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) commit(events []Event[T], before map[string]T) error {
+	evicted := db.evictOverflow(events)
+	for _, e := range evicted {
+		events = append(events, Event[T]{Key: e.key, Op: OpDelete})
+	}
+
+	// Indexes follow the data in memory, which keeps the change even if it
+	// cannot be persisted unless the caller undoes it.
+	db.updateIndexes(events)
+
+	var err error
+	if db.wal != nil {
+		err = db.logEvents(events)
+	} else {
+		err = db.persist()
+	}
+	if err != nil {
+		db.restoreEvictions(evicted)
+		return err
+	}
+	db.countEvents(events)
+	db.changes.record(events)
+	notable := db.notable(events, before)
+	db.runHooks(notable)
+	if db.hooks.onEvict != nil {
+		for _, e := range evicted {
+			db.hooks.onEvict(e.key, e.value)
+		}
+	}
+	db.publish(notable)
+	return nil
+}
+
+// flush writes the in-memory data to disk immediately.
+// In-memory databases have nothing to write.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) flush() error {
+	if db.cfg.memory {
+		db.dirty = false
+		return nil
+	}
+	if db.cfg.readOnly {
+		return ErrReadOnly
+	}
+
+	start := time.Now()
+	written, err := writeData(db.filepath, &envelope[T]{Data: db.data, Expires: db.expires}, &db.cfg)
+	if err != nil {
+		return err
+	}
+	if db.wal != nil {
+		if err := db.wal.truncate(); err != nil {
+			return err
+		}
+	}
+	db.dirty = false
+	if db.cfg.storage == nil {
+		db.recordFileState()
+	}
+	db.counters.countPersist(start)
+	db.cfg.logPersist(db.filepath, start, len(db.data), written)
+	return nil
+}
+
+// flushLoop writes pending changes to disk once per interval until the database is closed.
+// Failed flushes leave the data marked dirty so they are retried on the next tick.
+func (db *DB[T]) flushLoop(interval time.Duration) {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			db.mu.Lock()
+			if !db.closed && db.dirty {
+				_ = db.flush()
+			}
+			db.unlock()
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestAsyncPersist(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithAsyncPersist(time.Hour))
+	defer db.Close()
+	for i := 0; i < 100; i++ {
+		_ = db.Set("user:1", User{Name: "Alice", Age: i})
+	}
+
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("Expected write to be buffered until flush, got %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Failed to close database: %v", err)
+	}
+
+	after, _ := smalldb.Open[User](file)
+	defer after.Close()
+	if user, _ := after.Get("user:1"); user.Age != 99 {
+		t.Fatalf("Expected Close to flush the latest value, got %v", user)
+	}
+}
+
+func TestAsyncPersistFlushesOnInterval(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithAsyncPersist(10*time.Millisecond))
+	defer db.Close()
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	time.Sleep(100 * time.Millisecond)
+
+	contents, _ := os.ReadFile(file)
+	if !strings.Contains(string(contents), "user:1") {
+		t.Fatalf("Expected background flush to persist the write, got %q", contents)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithAsyncPersist(time.Hour))
+	defer db.Close()
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	if err := db.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	contents, _ := os.ReadFile(file)
+	if !strings.Contains(string(contents), "user:1") {
+		t.Fatalf("Expected Flush to persist pending writes, got %q", contents)
+	}
+}
+
+func TestSyncModes(t *testing.T) {
+	for _, mode := range []smalldb.SyncMode{smalldb.SyncFull, smalldb.SyncData, smalldb.SyncNone} {
+		for _, wal := range []bool{false, true} {
+			file := filepath.Join(t.TempDir(), "db.json")
+			opts := []smalldb.Option{smalldb.WithSync(mode)}
+			if wal {
+				opts = append(opts, smalldb.WithWAL())
+			}
+
+			db, _ := smalldb.Open[User](file, opts...)
+			if err := db.Set("user:1", User{Name: "Alice", Age: 30}); err != nil {
+				t.Fatalf("Failed to set value with sync mode %d: %v", mode, err)
+			}
+			_ = db.Close()
+
+			reopened, _ := smalldb.Open[User](file, opts...)
+			if user, ok := reopened.Get("user:1"); !ok || user.Name != "Alice" {
+				t.Fatalf("Expected Alice with sync mode %d, got %v (found: %v)", mode, user, ok)
+			}
+			_ = reopened.Close()
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package smalldb
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyValue is a single entry of the database.
+type KeyValue[T any] struct {
+	Key   string
+	Value T
+}
+
+// Filter returns a copy of all entries for which pred returns true.
+// pred is called under the read lock, so it must not call back into the database.
+// Iteration order is unspecified.
+func (db *DB[T]) Filter(pred func(key string, value T) bool) map[string]T {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	result := make(map[string]T)
+	if db.closed {
+		return result
+	}
+
+	now := time.Now()
+	for k, v := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		if pred(k, v) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// FindOne returns the first entry for which pred returns true.
+// Iteration order is unspecified, so if several entries match any one of them may be returned.
+func (db *DB[T]) FindOne(pred func(key string, value T) bool) (key string, value T, found bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return "", value, false
+	}
+
+	now := time.Now()
+	for k, v := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		if pred(k, v) {
+			return k, v, true
+		}
+	}
+	return "", value, false
+}
+
+// KeysWithValue returns the keys whose value equals value, in ascending order.
+// Values are compared with eq, or as WithEquality configures if eq is nil.
+// eq is called under the read lock, so it must not call back into the database.
+func (db *DB[T]) KeysWithValue(value T, eq func(a, b T) bool) []string {
+	if eq == nil {
+		eq = db.equal
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil
+	}
+
+	now := time.Now()
+	var keys []string
+	for k, v := range db.data {
+		if !db.isExpired(k, now) && eq(v, value) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Count returns the number of entries, or with predicates, the number of
+// entries for which every predicate returns true. Values are not copied.
+func (db *DB[T]) Count(preds ...func(key string, value T) bool) int {
+	if len(preds) == 0 {
+		return db.Len()
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return 0
+	}
+
+	now := time.Now()
+	count := 0
+entries:
+	for k, v := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		for _, pred := range preds {
+			if !pred(k, v) {
+				continue entries
+			}
+		}
+		count++
+	}
+	return count
+}
+
+// Scan returns a copy of all entries whose key starts with prefix.
+func (db *DB[T]) Scan(prefix string) map[string]T {
+	return db.Filter(func(key string, _ T) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// ScanKeys returns all keys that start with prefix, in no particular order,
+// without copying their values.
+func (db *DB[T]) ScanKeys(prefix string) []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	keys := []string{}
+	if db.closed {
+		return keys
+	}
+
+	now := time.Now()
+	for k := range db.data {
+		if strings.HasPrefix(k, prefix) && !db.isExpired(k, now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Page returns up to limit entries starting at offset, in ascending key order,
+// along with the total number of entries. Pages are stable across calls as long
+// as the data does not change in between. An offset past the end, or a limit of
+// zero or less, returns no entries.
+func (db *DB[T]) Page(offset, limit int) ([]KeyValue[T], int) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	page := []KeyValue[T]{}
+	if db.closed {
+		return page, 0
+	}
+
+	keys := db.sortedKeys()
+	total := len(keys)
+	offset = max(offset, 0)
+	if offset >= total || limit <= 0 {
+		return page, total
+	}
+
+	for _, k := range keys[offset:min(offset+limit, total)] {
+		page = append(page, KeyValue[T]{Key: k, Value: db.data[k]})
+	}
+	return page, total
+}
+
+// sortedKeys returns the keys of all live entries in ascending order.
+// The caller must hold db.mu.
+func (db *DB[T]) sortedKeys() []string {
+	now := time.Now()
+	keys := make([]string, 0, len(db.data))
+	for k := range db.data {
+		if !db.isExpired(k, now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
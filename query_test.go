@@ -0,0 +1,106 @@
+package smalldb_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestFilterAndFindOne(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+		"user:3": {Name: "Charlie", Age: 35},
+	})
+
+	over28 := db.Filter(func(key string, u User) bool { return u.Age > 28 })
+	if len(over28) != 2 || over28["user:2"].Name != "" {
+		t.Fatalf("Expected Alice and Charlie, got %v", over28)
+	}
+
+	key, user, found := db.FindOne(func(key string, u User) bool { return u.Name == "Bob" })
+	if !found || key != "user:2" || user.Age != 25 {
+		t.Fatalf("Expected to find Bob, got %q %v %v", key, user, found)
+	}
+
+	if _, _, found := db.FindOne(func(key string, u User) bool { return u.Age > 100 }); found {
+		t.Fatalf("Expected no match")
+	}
+}
+
+func TestScan(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1":      {Name: "Alice", Age: 30},
+		"user:2":      {Name: "Bob", Age: 25},
+		"session:abc": {Name: "Alice"},
+	})
+
+	users := db.Scan("user:")
+	if len(users) != 2 || users["user:1"].Name != "Alice" {
+		t.Fatalf("Expected two users, got %v", users)
+	}
+
+	keys := db.ScanKeys("session:")
+	if len(keys) != 1 || keys[0] != "session:abc" {
+		t.Fatalf("Expected [session:abc], got %v", keys)
+	}
+}
+
+func TestCount(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+		"user:3": {Name: "Charlie", Age: 35},
+	})
+
+	if n := db.Count(); n != 3 {
+		t.Fatalf("Expected 3 entries, got %d", n)
+	}
+
+	if n := db.Count(func(key string, u User) bool { return u.Age >= 30 }); n != 2 {
+		t.Fatalf("Expected 2 matching entries, got %d", n)
+	}
+}
+
+func TestKeysWithValue(t *testing.T) {
+	db := smalldb.OpenMemory[string]()
+	_ = db.SetMany(map[string]string{
+		"session:3": "alice",
+		"session:1": "alice",
+		"session:2": "bob",
+	})
+
+	if keys := db.KeysWithValue("alice", nil); !reflect.DeepEqual(keys, []string{"session:1", "session:3"}) {
+		t.Fatalf("Expected alice's sessions, got %v", keys)
+	}
+
+	ignoreCase := func(a, b string) bool { return strings.EqualFold(a, b) }
+	if keys := db.KeysWithValue("BOB", ignoreCase); !reflect.DeepEqual(keys, []string{"session:2"}) {
+		t.Fatalf("Expected bob's session, got %v", keys)
+	}
+}
+
+func TestPage(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5})
+
+	page, total := db.Page(1, 2)
+	want := []smalldb.KeyValue[int]{{Key: "b", Value: 2}, {Key: "c", Value: 3}}
+	if total != 5 || !reflect.DeepEqual(page, want) {
+		t.Fatalf("Expected %v of 5, got %v of %d", want, page, total)
+	}
+
+	page, _ = db.Page(4, 10)
+	if len(page) != 1 || page[0].Key != "e" {
+		t.Fatalf("Expected a short last page, got %v", page)
+	}
+
+	if page, total := db.Page(10, 2); len(page) != 0 || total != 5 {
+		t.Fatalf("Expected an empty page of 5, got %v of %d", page, total)
+	}
+}
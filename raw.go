@@ -0,0 +1,49 @@
+package smalldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// GetRaw returns the value stored under key encoded as JSON, for tools that
+// handle values without knowing T. The configured codec is used if it is a
+// JSONCodec, so its indentation applies; other codecs are bypassed in favour of
+// encoding/json. The bool reports whether the key exists, and the error is the
+// codec's if the value cannot be encoded.
+func (db *DB[T]) GetRaw(key string) (json.RawMessage, bool, error) {
+	value, exists := db.Get(key)
+	if !exists {
+		return nil, false, nil
+	}
+
+	codec, ok := db.cfg.codec.(JSONCodec)
+	if !ok {
+		codec = JSONCodec{}
+	}
+	raw, err := codec.Marshal(value)
+	if err != nil {
+		return nil, true, err
+	}
+	return raw, true, nil
+}
+
+// SetRaw decodes raw JSON into a T and stores it under key like Set, for
+// ingesting values that arrive already encoded. Decoding is strict: raw must be
+// a single JSON value and, for structs, must not contain fields T does not
+// have. If raw does not fit T nothing is stored and the error says why.
+func (db *DB[T]) SetRaw(key string, raw json.RawMessage) error {
+	var value T
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&value)
+	if err == nil && dec.More() {
+		err = errors.New("unexpected data after the value")
+	}
+	if err != nil {
+		return fmt.Errorf("smalldb: raw value for %q does not fit %v: %w", key, reflect.TypeFor[T](), err)
+	}
+	return db.Set(key, value)
+}
@@ -0,0 +1,45 @@
+package smalldb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestGetRaw(t *testing.T) {
+	db := smalldb.OpenMemory[User](smalldb.WithCompactJSON())
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	raw, ok, err := db.GetRaw("user:1")
+	if err != nil || !ok {
+		t.Fatalf("Expected the raw value, got found=%v err=%v", ok, err)
+	}
+	if string(raw) != `{"Name":"Alice","Age":30}` {
+		t.Fatalf("Unexpected raw value %s", raw)
+	}
+
+	if raw, ok, err := db.GetRaw("user:missing"); ok || raw != nil || err != nil {
+		t.Fatalf("Expected a missing key, got %s (found: %v, err: %v)", raw, ok, err)
+	}
+}
+
+func TestSetRaw(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+
+	if err := db.SetRaw("user:1", json.RawMessage(`{"Name":"Alice","Age":30}`)); err != nil {
+		t.Fatalf("Failed to set raw value: %v", err)
+	}
+	if user, _ := db.Get("user:1"); user != (User{Name: "Alice", Age: 30}) {
+		t.Fatalf("Expected Alice, got %v", user)
+	}
+
+	for _, raw := range []string{`{"Name":"Bob","Email":"bob@example.com"}`, `{"Age":"old"}`, `[1, 2]`, `{} {}`} {
+		if err := db.SetRaw("user:2", json.RawMessage(raw)); err == nil {
+			t.Errorf("Expected %s to be rejected", raw)
+		}
+	}
+	if db.Has("user:2") {
+		t.Fatal("Expected nothing to be stored for rejected values")
+	}
+}
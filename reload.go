@@ -0,0 +1,116 @@
+package smalldb
+
+import (
+	"os"
+	"time"
+)
+
+// Reload replaces the in-memory data with the current contents of the file.
+// Any in-memory changes that have not been written yet, such as writes pending
+// under WithAsyncPersist, are lost. Subscribers receive events for every key
+// the reload changed. In-memory databases have nothing to reload.
+func (db *DB[T]) Reload() error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	return db.reload()
+}
+
+// reload reads the file and swaps it in.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) reload() error {
+	if db.cfg.memory {
+		return nil
+	}
+
+	start := time.Now()
+	env, records, err := loadData[T](db.filepath, &db.cfg)
+	if err != nil {
+		return err
+	}
+	if db.wal != nil {
+		db.wal.records = records
+	}
+
+	old, oldExpires := db.data, db.expires
+	db.data = env.Data
+	db.expires = env.Expires
+	db.report = LoadReport{Quarantined: env.quarantined}
+	db.dirty = false
+	if db.cfg.storage == nil {
+		db.recordFileState()
+	}
+	db.rebuildIndexes()
+	db.resetLRU()
+
+	db.purgeExpired(time.Now())
+	if len(db.expires) > 0 {
+		db.startSweeper()
+	}
+
+	if db.hooks.onReload != nil {
+		db.hooks.onReload(old, cloneMap(db.data))
+	}
+	if db.changes.enabled() || db.hasSubscribers() {
+		events := db.diffEvents(old, db.data, oldExpires, db.expires, allKeys(old, db.data))
+		db.changes.record(events)
+		db.publish(db.notable(events, old))
+	}
+	db.cfg.logTiming("reload", db.filepath, start, "keys", len(db.data))
+	return nil
+}
+
+// reloadIfChanged reloads the data if WithAutoReload is set and the file has
+// changed since it was last read or written, reporting whether it did.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) reloadIfChanged() (bool, error) {
+	if db.cfg.reload <= 0 || db.cfg.memory || !db.fileChanged() {
+		return false, nil
+	}
+	return true, db.reload()
+}
+
+// recordFileState remembers the file's modification time and size so external
+// changes can be told apart from our own writes.
+func (db *DB[T]) recordFileState() {
+	db.modTime, db.size = time.Time{}, 0
+	if info, err := os.Stat(db.filepath); err == nil {
+		db.modTime, db.size = info.ModTime(), info.Size()
+	}
+}
+
+// fileChanged reports whether the file differs from the last recorded state.
+// The caller must hold db.mu.
+func (db *DB[T]) fileChanged() bool {
+	info, err := os.Stat(db.filepath)
+	if err != nil {
+		return !db.modTime.IsZero()
+	}
+	return !info.ModTime().Equal(db.modTime) || info.Size() != db.size
+}
+
+// watchLoop polls the file once per interval and reloads it when it changes,
+// until the database is closed. Failed reloads are retried on the next tick.
+func (db *DB[T]) watchLoop(interval time.Duration) {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			db.mu.Lock()
+			if !db.closed {
+				_, _ = db.reloadIfChanged()
+			}
+			db.unlock()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package smalldb_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestReload(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	writer, _ := smalldb.Open[User](file)
+	defer writer.Close()
+
+	reader, err := smalldb.Open[User](file, smalldb.WithReadOnly(), smalldb.WithoutLocking())
+	if err != nil {
+		t.Fatalf("Failed to open reader: %v", err)
+	}
+	defer reader.Close()
+
+	_ = writer.Set("user:1", User{Name: "Alice", Age: 30})
+	if reader.Has("user:1") {
+		t.Fatalf("Expected reader not to see the write before reloading")
+	}
+
+	if err := reader.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if !reader.Has("user:1") {
+		t.Fatalf("Expected reader to see the write after reloading")
+	}
+}
+
+func TestAutoReload(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	writer, _ := smalldb.Open[User](file)
+	defer writer.Close()
+
+	reader, _ := smalldb.Open[User](file,
+		smalldb.WithReadOnly(),
+		smalldb.WithoutLocking(),
+		smalldb.WithAutoReload(5*time.Millisecond),
+	)
+	defer reader.Close()
+
+	_ = writer.Set("user:1", User{Name: "Alice", Age: 30})
+
+	deadline := time.Now().Add(time.Second)
+	for !reader.Has("user:1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected reader to pick up the change automatically")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOnReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	writer, _ := smalldb.Open[User](file)
+	defer writer.Close()
+	_ = writer.Set("user:1", User{Name: "Alice", Age: 30})
+
+	var before, after map[string]User
+	reader, _ := smalldb.Open[User](file, smalldb.WithReadOnly(), smalldb.WithoutLocking(),
+		smalldb.WithOnReload(func(old, new map[string]User) {
+			before, after = old, new
+		}))
+	defer reader.Close()
+
+	_ = writer.Set("user:2", User{Name: "Bob", Age: 25})
+	if err := reader.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	if len(before) != 1 || len(after) != 2 || after["user:2"].Name != "Bob" {
+		t.Fatalf("Expected the hook to see 1 entry before and 2 after, got %v and %v", before, after)
+	}
+}
+
+func TestTransactionConflictsWithExternalWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[int](file, smalldb.WithoutLocking(), smalldb.WithAutoReload(time.Hour))
+	defer db.Close()
+	_ = db.Set("counter", 1)
+
+	other, _ := smalldb.Open[int](file, smalldb.WithoutLocking())
+	defer other.Close()
+
+	attempts := 0
+	err := db.TransactionRetry(2, func(tx *smalldb.Tx[int]) error {
+		attempts++
+		if attempts == 1 {
+			_ = other.Set("external", 42) // Another process writes mid-transaction.
+		}
+		tx.Update("counter", func(old int, _ bool) int { return old + 1 })
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the retried transaction to succeed, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("Expected the conflict to cause a retry, got %d attempts", attempts)
+	}
+
+	if value, _ := db.Get("external"); value != 42 {
+		t.Fatal("Expected the external write to survive the transaction")
+	}
+	if value, _ := db.Get("counter"); value != 2 {
+		t.Fatalf("Expected counter 2, got %d", value)
+	}
+
+	err = db.Transaction(func(tx *smalldb.Tx[int]) error {
+		_ = other.Set("external", 1000)
+		tx.Set("counter", 100)
+		return nil
+	})
+	if !errors.Is(err, smalldb.ErrConflict) {
+		t.Fatalf("Expected ErrConflict, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package smalldb
+
+import "time"
+
+// WithRollbackOnPersistError undoes a write in memory when it cannot be
+// persisted, such as when the disk is full, so reads never return data that is
+// not on disk. By default Set, SetWithTTL, Touch, Update, CompareAndSwap, Delete,
+// Clear, Restore and Transaction keep the change in memory and only return the
+// error; the batch methods, GetOrSet, SetIfAbsent, Rename, RenameNX, Replace
+// and RestoreBackup always roll back.
+// Under WithAsyncPersist writes are not persisted as they are made, so there is
+// nothing to roll back.
+func WithRollbackOnPersistError() Option {
+	return func(c *config) {
+		c.rollbackOnError = true
+	}
+}
+
+// saveForRollback captures the current state of keys so a write to them can be
+// undone if it fails to persist. It returns nil, which restores nothing, unless
+// WithRollbackOnPersistError is set.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) saveForRollback(keys ...string) *savedKeys[T] {
+	if !db.cfg.rollbackOnError {
+		return nil
+	}
+	return db.saveKeys(keys)
+}
+
+// rollbackSwap puts back the maps that a write replaced wholesale if it failed
+// to persist and WithRollbackOnPersistError is set.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) rollbackSwap(data map[string]T, expires map[string]time.Time) {
+	if db.cfg.rollbackOnError {
+		db.restoreSwap(data, expires)
+	}
+}
+
+// restoreSwap puts back the maps that a write replaced wholesale and rebuilds
+// the indexes to match.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) restoreSwap(data map[string]T, expires map[string]time.Time) {
+	db.data, db.expires = data, expires
+	db.rebuildIndexes()
+}
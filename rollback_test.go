@@ -0,0 +1,96 @@
+package smalldb_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+// fullDisk is storage whose writes fail once full is set.
+type fullDisk struct {
+	memStorage
+	full bool
+}
+
+func (d *fullDisk) Write(data []byte) error {
+	if d.full {
+		return errors.New("no space left on device")
+	}
+	return d.memStorage.Write(data)
+}
+
+func TestRollbackOnPersistError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	disk := &fullDisk{}
+
+	db, _ := smalldb.Open[int](file, smalldb.WithStorage(disk), smalldb.WithRollbackOnPersistError())
+	defer db.Close()
+	_ = db.SetMany(map[string]int{"a": 1, "b": 2})
+
+	disk.full = true
+	if err := db.Set("a", 10); err == nil {
+		t.Fatal("Expected Set to fail")
+	}
+	if err := db.Delete("b"); err == nil {
+		t.Fatal("Expected Delete to fail")
+	}
+	err := db.Transaction(func(tx *smalldb.Tx[int]) error {
+		tx.Set("c", 3)
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Expected Transaction to fail")
+	}
+	if err := db.Clear(); err == nil {
+		t.Fatal("Expected Clear to fail")
+	}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{"a": 1, "b": 2}) {
+		t.Fatalf("Expected the failed writes to be rolled back, got %v", got)
+	}
+}
+
+func TestPersistErrorKeepsChangeByDefault(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	disk := &fullDisk{full: true}
+
+	db, _ := smalldb.Open[int](file, smalldb.WithStorage(disk))
+	defer db.Close()
+
+	if err := db.Set("a", 1); err == nil {
+		t.Fatal("Expected Set to fail")
+	}
+	if value, ok := db.Get("a"); !ok || value != 1 {
+		t.Fatalf("Expected the change to remain in memory, got %d (found: %v)", value, ok)
+	}
+}
+
+func TestPersistErrorAlwaysRollsBackConditionalWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	disk := &fullDisk{}
+
+	db, _ := smalldb.Open[int](file, smalldb.WithStorage(disk))
+	defer db.Close()
+	_ = db.Set("a", 1)
+
+	disk.full = true
+	if _, _, err := db.GetOrSet("b", 2); err == nil {
+		t.Fatal("Expected GetOrSet to fail")
+	}
+	if _, err := db.SetIfAbsent("c", 3); err == nil {
+		t.Fatal("Expected SetIfAbsent to fail")
+	}
+	if err := db.Rename("a", "d"); err == nil {
+		t.Fatal("Expected Rename to fail")
+	}
+	if err := db.RenameNX("a", "e"); err == nil {
+		t.Fatal("Expected RenameNX to fail")
+	}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{"a": 1}) {
+		t.Fatalf("Expected the failed writes to be rolled back, got %v", got)
+	}
+}
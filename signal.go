@@ -0,0 +1,60 @@
+package smalldb
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// CloseOnSignal closes the database, flushing any writes still pending under
+// WithAsyncPersist, when the process receives one of sigs, or os.Interrupt or
+// SIGTERM if none are given. It returns a function that removes the handler;
+// the handler also removes itself once the database is closed by other means.
+//
+// Handlers the program installed itself with signal.Notify keep receiving the
+// signals. Once the database is closed the handler removes itself and sends the
+// signal to the process again, so it has the effect it would have had without
+// CloseOnSignal: by default the process exits, and a handler installed by the
+// program receives the signal a second time. Where a process cannot signal
+// itself, as on Windows, it exits with status 1 instead.
+func (db *DB[T]) CloseOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	done := make(chan struct{})
+	go func() {
+		defer signal.Stop(ch)
+
+		select {
+		case sig := <-ch:
+			if err := db.Close(); err != nil && db.cfg.logger != nil {
+				db.cfg.logger.Error("smalldb: close on signal", "path", db.filepath, "signal", sig.String(), "error", err)
+			}
+			signal.Stop(ch)
+			raise(sig)
+		case <-done:
+		case <-db.stop:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// raise sends sig to the current process, exiting if that is not possible.
+func raise(sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
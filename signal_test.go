@@ -0,0 +1,74 @@
+//go:build unix
+
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+// SIGWINCH is ignored by default, so resending it does not end the test binary.
+
+func TestCloseOnSignal(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithAsyncPersist(time.Hour))
+	stop := db.CloseOnSignal(syscall.SIGWINCH)
+	defer stop()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	own := make(chan os.Signal, 2)
+	signal.Notify(own, syscall.SIGWINCH)
+	defer signal.Stop(own)
+
+	_ = syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+
+	select {
+	case <-own:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the program's own handler to receive the signal")
+	}
+
+	// The file lock is released once Close has finished.
+	deadline := time.Now().Add(5 * time.Second)
+	reopened, err := smalldb.Open[User](file)
+	for errors.Is(err, smalldb.ErrLocked) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		reopened, err = smalldb.Open[User](file)
+	}
+	if err != nil {
+		t.Fatalf("Expected the signal to close the database, got %v", err)
+	}
+	defer reopened.Close()
+
+	if err := db.Set("user:2", User{}); !errors.Is(err, smalldb.ErrClosed) {
+		t.Fatalf("Expected ErrClosed after the signal, got %v", err)
+	}
+	if !reopened.Has("user:1") {
+		t.Fatalf("Expected the pending write to be flushed on close")
+	}
+}
+
+func TestCloseOnSignalStop(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	defer db.Close()
+
+	own := make(chan os.Signal, 1)
+	signal.Notify(own, syscall.SIGWINCH)
+	defer signal.Stop(own)
+
+	db.CloseOnSignal(syscall.SIGWINCH)()
+	_ = syscall.Kill(os.Getpid(), syscall.SIGWINCH)
+	<-own
+
+	if err := db.Set("user:1", User{}); err != nil {
+		t.Fatalf("Expected the database to stay open after stop, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package smalldb
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Snapshot writes a consistent copy of the database to w using the configured codec.
+// The read lock is held while encoding, so writers wait but readers do not.
+// The output has the same layout as the database file and can be loaded with Restore.
+func (db *DB[T]) Snapshot(w io.Writer) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	return encodeData(w, &envelope[T]{Data: db.data, Expires: db.expires}, &db.cfg)
+}
+
+// ExportJSON writes a point-in-time copy of the database to w as JSON, in the
+// same layout as a JSON database file, whatever codec and format are configured.
+// Unlike Snapshot, the lock is held only while the entries are copied, not
+// while they are encoded and written, so a slow w does not hold up writers.
+// Writes made after the copy is taken are not included. Values are copied
+// shallowly, so values holding pointers, maps or slices must not be mutated in
+// place while the export runs.
+func (db *DB[T]) ExportJSON(w io.Writer) error {
+	db.mu.RLock()
+	if db.closed {
+		db.mu.RUnlock()
+		return ErrClosed
+	}
+	now := time.Now()
+	env := &envelope[T]{
+		Data:    make(map[string]T, len(db.data)),
+		Expires: make(map[string]time.Time, len(db.expires)),
+	}
+	for k, v := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		env.Data[k] = v
+		if expiry, ok := db.expires[k]; ok {
+			env.Expires[k] = expiry
+		}
+	}
+	db.mu.RUnlock()
+
+	cfg := db.cfg
+	if _, ok := cfg.codec.(JSONCodec); !ok {
+		cfg.codec = JSONCodec{Indent: "  "}
+	}
+	cfg.format = JSON
+	return encodeData(w, env, &cfg)
+}
+
+// Restore replaces all data in the database with a snapshot read from r and persists it.
+// The existing data is left untouched if the snapshot cannot be read or decoded.
+func (db *DB[T]) Restore(r io.Reader) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	env, err := decodeData[T](raw, &db.cfg)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if err := db.checkInvariants(env.Data); err != nil {
+		return err
+	}
+
+	oldData, oldExpires := db.data, db.expires
+	db.data = env.Data
+	db.expires = env.Expires
+	db.purgeExpired(time.Now())
+	if len(db.expires) > 0 {
+		db.startSweeper()
+	}
+	if err := db.commit(db.diffEvents(oldData, db.data, oldExpires, db.expires, allKeys(oldData, db.data)), oldData); err != nil {
+		db.rollbackSwap(oldData, oldExpires)
+		return err
+	}
+	return nil
+}
+
+// CopyTo opens a new database at newPath with opts and fills it with a
+// point-in-time copy of this one, including TTLs, while this database stays
+// open and usable. Only the read lock is held while the entries are copied.
+// It fails if a file already exists at newPath. The copy is checked against
+// any validators and invariants given in opts before it is written.
+func (db *DB[T]) CopyTo(newPath string, opts ...Option) (*DB[T], error) {
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, fmt.Errorf("smalldb: copying to %s: %w", newPath, fs.ErrExist)
+	}
+
+	db.mu.RLock()
+	if db.closed {
+		db.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	now := time.Now()
+	data := make(map[string]T, len(db.data))
+	expires := make(map[string]time.Time)
+	for k, v := range db.data {
+		if db.isExpired(k, now) {
+			continue
+		}
+		data[k] = v
+		if expiry, ok := db.expires[k]; ok {
+			expires[k] = expiry
+		}
+	}
+	db.mu.RUnlock()
+
+	target, err := Open[T](newPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := target.load(data, expires); err != nil {
+		target.Close()
+		return nil, err
+	}
+	return target, nil
+}
+
+// load replaces the contents of an empty database with data and expires and persists them.
+func (db *DB[T]) load(data map[string]T, expires map[string]time.Time) error {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if err := db.validateKeys(data, allKeys(data)); err != nil {
+		return err
+	}
+	if err := db.checkInvariants(data); err != nil {
+		return err
+	}
+
+	db.data, db.expires = data, expires
+	if len(db.expires) > 0 {
+		db.startSweeper()
+	}
+	return db.commit(db.diffEvents(nil, data, nil, expires, allKeys(data)), nil)
+}
@@ -0,0 +1,118 @@
+package smalldb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestSnapshotAndRestore(t *testing.T) {
+	source := smalldb.OpenMemory[User]()
+	_ = source.SetMany(map[string]User{
+		"user:1": {Name: "Alice", Age: 30},
+		"user:2": {Name: "Bob", Age: 25},
+	})
+
+	var buf bytes.Buffer
+	if err := source.Snapshot(&buf); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	file := "test_db.json"
+	defer cleanup(file)
+
+	target, _ := smalldb.Open[User](file)
+	defer target.Close()
+	_ = target.Set("user:3", User{Name: "Charlie", Age: 28})
+
+	if err := target.Restore(&buf); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+
+	if !reflect.DeepEqual(source.GetAll(), target.GetAll()) {
+		t.Fatalf("Expected %v, got %v", source.GetAll(), target.GetAll())
+	}
+
+	_ = target.Close()
+	reopened, _ := smalldb.Open[User](file)
+	defer reopened.Close()
+	if !reflect.DeepEqual(source.GetAll(), reopened.GetAll()) {
+		t.Fatalf("Expected restored data to be persisted, got %v", reopened.GetAll())
+	}
+}
+
+// writeHook is an io.Writer that runs fn before each write.
+type writeHook struct {
+	buf bytes.Buffer
+	fn  func()
+}
+
+func (w *writeHook) Write(p []byte) (int, error) {
+	w.fn()
+	return w.buf.Write(p)
+}
+
+func TestExportJSONDoesNotHoldLock(t *testing.T) {
+	db := smalldb.OpenMemory[User](smalldb.WithCodec(smalldb.GobCodec{}))
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	// A write from inside the export would deadlock if the lock were still held.
+	w := &writeHook{fn: func() { _ = db.Set("user:2", User{Name: "Bob", Age: 25}) }}
+	if err := db.ExportJSON(w); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	var exported map[string]User
+	if err := json.Unmarshal(w.buf.Bytes(), &exported); err != nil {
+		t.Fatalf("Expected JSON output, got %v", err)
+	}
+	want := map[string]User{"user:1": {Name: "Alice", Age: 30}}
+	if !reflect.DeepEqual(exported, want) {
+		t.Fatalf("Expected a point-in-time copy %v, got %v", want, exported)
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := smalldb.Open[User](filepath.Join(dir, "db.json"))
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.SetWithTTL("session:1", User{Name: "Eve"}, time.Hour)
+
+	copyPath := filepath.Join(dir, "copy.json")
+	copied, err := db.CopyTo(copyPath)
+	if err != nil {
+		t.Fatalf("Failed to copy database: %v", err)
+	}
+	_ = copied.Set("user:2", User{Name: "Bob", Age: 25})
+	_ = copied.Close()
+
+	if db.Has("user:2") {
+		t.Fatal("Expected the source to be unaffected by writes to the copy")
+	}
+	_ = db.Set("user:3", User{Name: "Charlie", Age: 35})
+
+	reopened, err := smalldb.Open[User](copyPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen copy: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 3 || !reopened.Has("session:1") || reopened.Has("user:3") {
+		t.Fatalf("Unexpected copy contents: %v", reopened.Keys())
+	}
+	if raw, _ := os.ReadFile(copyPath); !bytes.Contains(raw, []byte(`"expires"`)) {
+		t.Fatalf("Expected the TTL to be copied, got %s", raw)
+	}
+
+	if _, err := db.CopyTo(copyPath); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected fs.ErrExist when the target exists, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package smalldb
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time view of a database's activity and file.
+type Stats struct {
+	Keys                int           // Number of live entries.
+	Reads               uint64        // Keys looked up by Get, GetContext, Has and GetMany.
+	Writes              uint64        // Keys stored by any write method or transaction.
+	Deletes             uint64        // Keys removed by any write method or transaction.
+	Persists            uint64        // Successful writes to disk, including WAL appends.
+	LastPersistDuration time.Duration // How long the most recent persist took.
+	FileSize            int64         // Size of the database file when last written or loaded.
+	FileModTime         time.Time     // Modification time of the database file at that point.
+}
+
+// counters holds the activity counters reported by Stats.
+type counters struct {
+	reads       atomic.Uint64
+	writes      atomic.Uint64
+	deletes     atomic.Uint64
+	persists    atomic.Uint64
+	lastPersist atomic.Int64
+}
+
+// Stats returns the database's activity counters and file metadata.
+// Counters start at zero when the database is opened.
+func (db *DB[T]) Stats() Stats {
+	db.mu.RLock()
+	fileSize, modTime := db.size, db.modTime
+	db.mu.RUnlock()
+
+	return Stats{
+		Keys:                db.Len(),
+		Reads:               db.counters.reads.Load(),
+		Writes:              db.counters.writes.Load(),
+		Deletes:             db.counters.deletes.Load(),
+		Persists:            db.counters.persists.Load(),
+		LastPersistDuration: time.Duration(db.counters.lastPersist.Load()),
+		FileSize:            fileSize,
+		FileModTime:         modTime,
+	}
+}
+
+// countEvents adds the changes described by events to the counters.
+func (db *DB[T]) countEvents(events []Event[T]) {
+	for _, e := range events {
+		switch e.Op {
+		case OpSet:
+			db.counters.writes.Add(1)
+		case OpDelete:
+			db.counters.deletes.Add(1)
+		}
+	}
+}
+
+// countPersist records a successful persist that began at start.
+func (c *counters) countPersist(start time.Time) {
+	c.persists.Add(1)
+	c.lastPersist.Store(int64(time.Since(start)))
+}
+
+// FileInfo stats the database file as it is on disk now. It returns
+// errors.ErrUnsupported for in-memory databases and those opened WithStorage,
+// which have no file, and ErrClosed once the database is closed.
+func (db *DB[T]) FileInfo() (os.FileInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+	if db.cfg.memory || db.cfg.storage != nil {
+		return nil, errors.ErrUnsupported
+	}
+	return os.Stat(db.filepath)
+}
+
+// SizeOnDisk returns the size in bytes of the database file. See FileInfo.
+func (db *DB[T]) SizeOnDisk() (int64, error) {
+	info, err := db.FileInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// LastModified returns when the database file was last written. See FileInfo.
+func (db *DB[T]) LastModified() (time.Time, error) {
+	info, err := db.FileInfo()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
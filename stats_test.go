@@ -0,0 +1,58 @@
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestStats(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.SetMany(map[string]User{"user:2": {Name: "Bob"}, "user:3": {Name: "Charlie"}})
+	_ = db.Delete("user:3")
+	db.Get("user:1")
+	db.Has("user:2")
+	db.GetMany([]string{"user:1", "user:missing"})
+
+	stats := db.Stats()
+	if stats.Keys != 2 || stats.Writes != 3 || stats.Deletes != 1 || stats.Reads != 4 {
+		t.Fatalf("Unexpected counters: %+v", stats)
+	}
+	if stats.Persists != 3 {
+		t.Fatalf("Expected 3 persists, got %d", stats.Persists)
+	}
+	if stats.FileSize == 0 || stats.FileModTime.IsZero() {
+		t.Fatalf("Expected file metadata, got %+v", stats)
+	}
+}
+
+func TestFileInfo(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if size, err := db.SizeOnDisk(); err != nil || size != info.Size() {
+		t.Fatalf("Expected size %d, got %d (err: %v)", info.Size(), size, err)
+	}
+	if modTime, err := db.LastModified(); err != nil || !modTime.Equal(info.ModTime()) {
+		t.Fatalf("Expected modification time %v, got %v (err: %v)", info.ModTime(), modTime, err)
+	}
+
+	if _, err := smalldb.OpenMemory[User]().FileInfo(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expected ErrUnsupported for an in-memory database, got %v", err)
+	}
+}
@@ -1,49 +1,251 @@
 package smalldb
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"os"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"time"
 )
 
-// readData reads the JSON data from the file into a map.
-func readData[T any](filepath string) (map[string]T, error) {
-	data := make(map[string]T)
+// envelopeFormat identifies files written in the envelope layout.
+const envelopeFormat = 1
 
-	fileData, err := ioutil.ReadFile(filepath)
+// envelope is the on-disk layout used when metadata must be stored alongside the data.
+// Files without metadata are written as a plain map for compatibility.
+type envelope[T any] struct {
+	Format  int                  `json:"$smalldb"`
+	Version int                  `json:"version,omitempty"`
+	Data    map[string]T         `json:"data"`
+	Expires map[string]time.Time `json:"expires,omitempty"`
+
+	quarantined []QuarantinedRecord // Values skipped by WithLenientLoad; never stored.
+}
+
+// hasMetadata reports whether the envelope layout is needed to store env.
+func (env *envelope[T]) hasMetadata() bool {
+	return len(env.Expires) > 0 || env.Version != 0
+}
+
+// readData reads the encoded data from the file, or from the storage set with WithStorage.
+func readData[T any](filepath string, cfg *config) (*envelope[T], error) {
+	fileData, err := storageFor(filepath, cfg).Read()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return data, nil // Return empty data if file doesn't exist.
+		if errors.Is(err, fs.ErrNotExist) {
+			return decodeData[T](nil, cfg) // Return empty data if file doesn't exist.
 		}
 		return nil, err
 	}
 
-	if len(fileData) == 0 {
-		return data, nil // Return empty data if file is empty.
+	raw, err := unpack(fileData, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeData[T](raw, cfg)
+}
+
+// decodeData decodes bytes produced by encodeData, accepting both the plain
+// map layout and the envelope layout, or records of the JSONLines format.
+func decodeData[T any](raw []byte, cfg *config) (*envelope[T], error) {
+	env := &envelope[T]{
+		Data:    make(map[string]T, max(cfg.capacity, 0)),
+		Expires: make(map[string]time.Time),
+	}
+
+	if len(raw) == 0 {
+		return env, nil // Return empty data if there is nothing to decode.
+	}
+
+	if cfg.format == JSONLines {
+		if err := readJSONLines(raw, env, cfg.logger); err != nil {
+			return nil, err
+		}
+		return env, nil
+	}
+
+	if cfg.schemaVersion > 0 || cfg.lenient || cfg.valueCompression > 0 {
+		return decodeEach(raw, env, cfg)
 	}
 
-	if err := json.Unmarshal(fileData, &data); err != nil {
+	decoded := envelope[T]{Data: env.Data} // Decode into the pre-sized map.
+	if err := cfg.codec.Unmarshal(raw, &decoded); err == nil && decoded.Format == envelopeFormat {
+		if decoded.Data != nil {
+			env.Data = decoded.Data
+		}
+		if decoded.Expires != nil {
+			env.Expires = decoded.Expires
+		}
+		return env, nil
+	}
+
+	clear(env.Data)
+	if err := cfg.codec.Unmarshal(raw, &env.Data); err != nil {
+		if _, ok := cfg.codec.(JSONCodec); ok {
+			return nil, diagnoseJSON[T](raw, err)
+		}
 		return nil, err
 	}
 
-	return data, nil
+	return env, nil
 }
 
-// writeData writes the JSON data to the file.
-func writeData[T any](filepath string, data map[string]T) error {
-	file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+// encodeData writes env to w with the configured codec, using the plain map
+// layout unless there is metadata to store. The JSON codec streams one entry
+// at a time so memory use is bounded by the largest value rather than the dataset.
+// The JSONLines format writes one record per line instead.
+func encodeData[T any](w io.Writer, env *envelope[T], cfg *config) error {
+	if cfg.format == JSONLines {
+		return writeJSONLines(w, env)
+	}
+
+	env.Version = cfg.schemaVersion
+	if env.hasMetadata() {
+		env.Format = envelopeFormat
+	}
+
+	if codec, ok := cfg.codec.(JSONCodec); ok {
+		if cfg.valueCompression > 0 {
+			return streamCompressed(w, env, codec, cfg.valueCompression)
+		}
+		return streamJSON(w, env, codec)
+	}
+
+	var payload any = env.Data
+	if env.hasMetadata() {
+		payload = env
+	}
+
+	encoded, err := cfg.codec.Marshal(payload)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	_, err = w.Write(encoded)
+	return err
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
+// newPackWriter returns a writer that applies the configured file transformations
+// (compression, then encryption, then checksum) to everything written to it,
+// passing the result on to w. Close must be called to finish the output.
+// Compression streams; encryption and checksums need the whole payload, so
+// enabling either buffers the output until Close.
+func newPackWriter(w io.Writer, cfg *config) io.WriteCloser {
+	var out io.WriteCloser = nopWriteCloser{w}
+	if cfg.encryptionKey != nil || cfg.checksum {
+		out = &sealWriter{dst: w, cfg: cfg}
+	}
+	if cfg.compression == Gzip {
+		out = &gzipWriteCloser{Writer: gzip.NewWriter(out), next: out}
+	}
+	return out
+}
+
+// nopWriteCloser adds a no-op Close to a writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// gzipWriteCloser finishes the gzip stream and then closes the writer beneath it.
+type gzipWriteCloser struct {
+	*gzip.Writer
+	next io.Closer
+}
+
+func (g *gzipWriteCloser) Close() error {
+	if err := g.Writer.Close(); err != nil {
 		return err
 	}
+	return g.next.Close()
+}
+
+// sealWriter buffers its input and, on Close, encrypts and checksums it as
+// configured before writing it to dst.
+type sealWriter struct {
+	buf bytes.Buffer
+	dst io.Writer
+	cfg *config
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+func (s *sealWriter) Close() error {
+	sealed := s.buf.Bytes()
+	if s.cfg.encryptionKey != nil {
+		var err error
+		if sealed, err = encrypt(sealed, s.cfg.encryptionKey); err != nil {
+			return err
+		}
+	}
+	if s.cfg.checksum {
+		sealed = addChecksum(sealed)
+	}
+	_, err := s.dst.Write(sealed)
+	return err
+}
+
+// unpack reverses the transformations applied by newPackWriter on data read from disk.
+// Compressed data is detected by its magic bytes, so files written with a
+// different compression setting can still be read.
+// Checksummed data is likewise verified whenever a checksum header is present.
+func unpack(raw []byte, cfg *config) ([]byte, error) {
+	var err error
+	if hasChecksum(raw) {
+		if raw, err = verifyChecksum(raw); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.encryptionKey != nil {
+		if raw, err = decrypt(raw, cfg.encryptionKey); err != nil {
+			return nil, err
+		}
+	}
+	if isGzip(raw) {
+		return gunzipBytes(raw)
+	}
+	return raw, nil
+}
+
+// writeData writes the encoded data to the file, or to the storage set with
+// WithStorage, and returns the number of bytes written. Storage that supports
+// it receives the data as a stream; otherwise it is encoded in memory and
+// written in one call.
+func writeData[T any](filepath string, env *envelope[T], cfg *config) (int64, error) {
+	var written int64
+	encode := func(w io.Writer) error {
+		packed := newPackWriter(&countingWriter{w: w, n: &written}, cfg)
+		if err := encodeData(packed, env, cfg); err != nil {
+			return err
+		}
+		return packed.Close()
+	}
+
+	st := storageFor(filepath, cfg)
+	if streaming, ok := st.(streamingStorage); ok {
+		return written, streaming.writeWith(encode)
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		return 0, err
+	}
+	return written, st.Write(buf.Bytes())
+}
+
+// countingWriter adds the number of bytes written through it to n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
 
-	return nil
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
 }
 
 // cloneMap creates a shallow copy of the map.
@@ -0,0 +1,79 @@
+package smalldb_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+// flaky is a value whose JSON encoding can be made to fail on demand.
+type flaky struct {
+	Value string
+	Fail  bool
+}
+
+func (f flaky) MarshalJSON() ([]byte, error) {
+	if f.Fail {
+		return nil, errors.New("marshal failed")
+	}
+	type plain flaky
+	return json.Marshal(plain(f))
+}
+
+func TestAtomicWriteKeepsOldFile(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[flaky](file)
+	defer db.Close()
+	if err := db.Set("good", flaky{Value: "kept"}); err != nil {
+		t.Fatalf("Failed to set data: %v", err)
+	}
+
+	if err := db.Set("bad", flaky{Value: "lost", Fail: true}); err == nil {
+		t.Fatalf("Expected write to fail")
+	}
+
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("Expected temp file to be removed, got %v", err)
+	}
+
+	_ = db.Close()
+	reopened, err := smalldb.Open[flaky](file)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+
+	value, exists := reopened.Get("good")
+	if !exists || value.Value != "kept" {
+		t.Fatalf("Expected previous data to survive, got %v", value)
+	}
+
+	if _, exists := reopened.Get("bad"); exists {
+		t.Fatalf("Expected failed write not to be persisted")
+	}
+}
+
+func TestInvalidFormatDiagnostics(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	cases := map[string]string{
+		`[1, 2, 3]`: "got array",
+		`"hello"`:   "got string",
+		`{"user:1": {"Name": "Alice", "Age": 30}, "user:2": {"Name": "Bob", "Age": "old"}}`: `key "user:2"`,
+	}
+	for content, want := range cases {
+		_ = os.WriteFile(file, []byte(content), 0644)
+
+		_, err := smalldb.Open[User](file)
+		if !errors.Is(err, smalldb.ErrInvalidFormat) || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Expected ErrInvalidFormat mentioning %q for %s, got %v", want, content, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package smalldb
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// streamJSON writes env as JSON one entry at a time, producing the same bytes
+// as codec.Marshal would for the plain map or envelope layout.
+func streamJSON[T any](w io.Writer, env *envelope[T], codec JSONCodec) error {
+	jw := &jsonWriter{w: bufio.NewWriter(w), codec: codec}
+
+	if env.Format == 0 {
+		writeJSONMap(jw, env.Data, 0)
+		return jw.flush()
+	}
+
+	jw.raw("{")
+	jw.field(1, "$smalldb")
+	jw.value(env.Format, 1)
+	jw.raw(",")
+	if env.Version != 0 {
+		jw.field(1, "version")
+		jw.value(env.Version, 1)
+		jw.raw(",")
+	}
+	jw.field(1, "data")
+	writeJSONMap(jw, env.Data, 1)
+	if len(env.Expires) > 0 {
+		jw.raw(",")
+		jw.field(1, "expires")
+		jw.value(env.Expires, 1)
+	}
+	jw.newline(0)
+	jw.raw("}")
+	return jw.flush()
+}
+
+// writeJSONMap writes m as a JSON object nested level deep, with keys in
+// sorted order to match encoding/json.
+func writeJSONMap[T any](jw *jsonWriter, m map[string]T, level int) {
+	if len(m) == 0 {
+		jw.raw("{}")
+		return
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	jw.raw("{")
+	for i, k := range keys {
+		if i > 0 {
+			jw.raw(",")
+		}
+		jw.field(level+1, k)
+		jw.value(m[k], level+1)
+	}
+	jw.newline(level)
+	jw.raw("}")
+}
+
+// jsonWriter emits JSON fragments with the codec's indentation and remembers
+// the first error so callers can check once at the end.
+type jsonWriter struct {
+	w     *bufio.Writer
+	codec JSONCodec
+	err   error
+}
+
+// indented reports whether output spans multiple lines.
+func (jw *jsonWriter) indented() bool {
+	return jw.codec.Prefix != "" || jw.codec.Indent != ""
+}
+
+// raw writes s verbatim.
+func (jw *jsonWriter) raw(s string) {
+	if jw.err == nil {
+		_, jw.err = jw.w.WriteString(s)
+	}
+}
+
+// newline starts a new line indented to level when output is indented.
+func (jw *jsonWriter) newline(level int) {
+	if jw.indented() {
+		jw.raw("\n" + jw.codec.Prefix + strings.Repeat(jw.codec.Indent, level))
+	}
+}
+
+// field writes an object key and its colon at the given level.
+func (jw *jsonWriter) field(level int, key string) {
+	jw.newline(level)
+	jw.value(key, level)
+	if jw.indented() {
+		jw.raw(": ")
+	} else {
+		jw.raw(":")
+	}
+}
+
+// value writes v encoded as JSON, indented as if nested level deep.
+func (jw *jsonWriter) value(v any, level int) {
+	if jw.err != nil {
+		return
+	}
+
+	var encoded []byte
+	if jw.indented() {
+		encoded, jw.err = json.MarshalIndent(v, jw.codec.Prefix+strings.Repeat(jw.codec.Indent, level), jw.codec.Indent)
+	} else {
+		encoded, jw.err = json.Marshal(v)
+	}
+	if jw.err == nil {
+		_, jw.err = jw.w.Write(encoded)
+	}
+}
+
+// flush writes any buffered output and returns the first error encountered.
+func (jw *jsonWriter) flush() error {
+	if jw.err != nil {
+		return jw.err
+	}
+	return jw.w.Flush()
+}
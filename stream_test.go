@@ -0,0 +1,72 @@
+package smalldb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestStreamedJSONMatchesEncodingJSON(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	items := make(map[string]User)
+	for i := 0; i < 50; i++ {
+		items[fmt.Sprintf("user:%02d", i)] = User{Name: "<Alice & Bob>", Age: i}
+	}
+
+	for _, layout := range [][2]string{{"", "  "}, {"", "\t"}, {" ", "  "}, {"", ""}} {
+		prefix, indent := layout[0], layout[1]
+		db, _ := smalldb.Open[User](file, smalldb.WithIndent(prefix, indent))
+		_ = db.SetMany(items)
+		_ = db.Close()
+
+		var want []byte
+		if prefix == "" && indent == "" {
+			want, _ = json.Marshal(items)
+		} else {
+			want, _ = json.MarshalIndent(items, prefix, indent)
+		}
+
+		got, _ := os.ReadFile(file)
+		if string(got) != string(want) {
+			t.Fatalf("Streamed output with prefix %q and indent %q differs from encoding/json:\n%s\nwant:\n%s", prefix, indent, got, want)
+		}
+	}
+
+	db, _ := smalldb.Open[User](file)
+	_ = db.SetWithTTL("session:1", User{Name: "Eve"}, time.Hour)
+	_ = db.Close()
+
+	reopened, err := smalldb.Open[User](file)
+	if err != nil {
+		t.Fatalf("Failed to reopen streamed envelope: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != len(items)+1 {
+		t.Fatalf("Expected %d entries, got %d", len(items)+1, reopened.Len())
+	}
+}
+
+func TestJSONOutputIsStable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[User](file)
+	for i := 0; i < 50; i++ {
+		_ = db.Set(fmt.Sprintf("user:%02d", 49-i), User{Name: "Alice", Age: i})
+	}
+	first, _ := os.ReadFile(file)
+	_ = db.Flush()
+	second, _ := os.ReadFile(file)
+	_ = db.Close()
+
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Expected rewriting unchanged data to produce identical bytes:\n%s\nthen:\n%s", first, second)
+	}
+}
@@ -0,0 +1,59 @@
+package smalldb
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SyncMode controls how hard the database works to get each write onto stable
+// storage before the write returns.
+type SyncMode int
+
+const (
+	// SyncFull flushes the file's data and metadata to the device with fsync
+	// before a write returns, and then the directory the file was renamed or
+	// created in, so it survives a power failure. It is the default.
+	SyncFull SyncMode = iota
+
+	// SyncData flushes the file's data but not all of its metadata, using
+	// fdatasync where the platform has it and fsync elsewhere. It is usually
+	// cheaper than SyncFull, at the risk of metadata such as the modification
+	// time being stale after a power failure.
+	SyncData
+
+	// SyncNone leaves flushing to the operating system. A crash of the process
+	// loses nothing, since the data is already with the kernel, but a power
+	// failure or kernel crash can lose recent writes. The temporary file is
+	// still renamed into place, so the file is never left half-written by the
+	// process itself.
+	SyncNone
+)
+
+// WithSync sets how files are synced to disk when the database is written,
+// including the write-ahead log of WithWAL. The default is SyncFull.
+func WithSync(mode SyncMode) Option {
+	return func(c *config) {
+		c.sync = mode
+	}
+}
+
+// syncFile flushes file to stable storage as mode requires.
+func syncFile(file *os.File, mode SyncMode) error {
+	switch mode {
+	case SyncNone:
+		return nil
+	case SyncData:
+		return syncData(file)
+	default:
+		return file.Sync()
+	}
+}
+
+// syncDirOf flushes the directory containing path if mode is SyncFull, so a
+// file just renamed or created there is not lost with the directory entry.
+func syncDirOf(path string, mode SyncMode) error {
+	if mode != SyncFull {
+		return nil
+	}
+	return syncDir(filepath.Dir(path))
+}
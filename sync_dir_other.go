@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package smalldb
+
+// syncDir does nothing where directories cannot be synced, such as on
+// Windows, whose renames are made durable by the file system itself.
+func syncDir(string) error {
+	return nil
+}
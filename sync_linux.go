@@ -0,0 +1,13 @@
+//go:build linux
+
+package smalldb
+
+import (
+	"os"
+	"syscall"
+)
+
+// syncData flushes the file's data, but not necessarily its metadata, with fdatasync.
+func syncData(file *os.File) error {
+	return syscall.Fdatasync(int(file.Fd()))
+}
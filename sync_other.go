@@ -0,0 +1,10 @@
+//go:build !linux
+
+package smalldb
+
+import "os"
+
+// syncData falls back to a full fsync on platforms without fdatasync.
+func syncData(file *os.File) error {
+	return file.Sync()
+}
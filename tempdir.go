@@ -0,0 +1,94 @@
+package smalldb
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// WithTempDir writes the temporary file used for each atomic write into dir
+// instead of next to the database file, for when the database's directory is
+// short on space. If dir is on a different filesystem the temporary file
+// cannot be renamed into place, so it is first copied next to the database
+// file and renamed from there, and a warning is logged through WithLogger.
+// Keep dir on the same filesystem to avoid the extra copy. Each write uses a
+// uniquely named file, so several databases may share dir; files left there by
+// a crash are not cleaned up.
+func WithTempDir(dir string) Option {
+	return func(c *config) {
+		c.tempDir = dir
+	}
+}
+
+// createTemp creates the temporary file for a write: <path>.tmp next to the
+// database file, or a uniquely named file in the WithTempDir directory, which
+// databases with the same file name in other directories may share.
+func (s fileStorage) createTemp() (*os.File, error) {
+	if s.tmpDir == "" {
+		return os.OpenFile(s.path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, s.mode)
+	}
+
+	file, err := os.CreateTemp(s.tmpDir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(s.mode); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// renameInto moves the finished temporary file tmp over the file at s.path
+// and syncs the directory as the SyncMode requires. When tmp is on another
+// filesystem it is copied next to the target and renamed from there, so the
+// replacement is still atomic.
+func (s fileStorage) renameInto(tmp string) error {
+	err := os.Rename(tmp, s.path)
+	if err == nil {
+		return syncDirOf(s.path, s.sync)
+	}
+	if !isCrossDevice(err) {
+		return err
+	}
+
+	if s.logger != nil {
+		s.logger.Warn("smalldb: temporary directory is on another filesystem, copying instead of renaming",
+			slog.String("path", s.path), slog.String("tmp", tmp))
+	}
+
+	local := s.path + ".tmp"
+	if err := copyFile(tmp, local, s.mode); err != nil {
+		os.Remove(local)
+		return err
+	}
+	if err := syncPath(local, s.sync); err != nil {
+		os.Remove(local)
+		return err
+	}
+	if err := os.Rename(local, s.path); err != nil {
+		os.Remove(local)
+		return err
+	}
+	if err := syncDirOf(s.path, s.sync); err != nil {
+		return err
+	}
+	return os.Remove(tmp)
+}
+
+// syncPath opens the file at path and syncs it as mode requires.
+func syncPath(path string, mode SyncMode) error {
+	if mode == SyncNone {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := syncFile(file, mode); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
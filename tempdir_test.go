@@ -0,0 +1,94 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestWithTempDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	tmpDir := t.TempDir()
+
+	db, _ := smalldb.Open[User](file, smalldb.WithTempDir(tmpDir))
+	if err := db.Set("user:1", User{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	_ = db.Close()
+
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 0 {
+		t.Fatalf("Expected the temporary directory to be left empty, found %d entries", len(entries))
+	}
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("Expected no temporary file next to the database, got %v", err)
+	}
+
+	reopened, _ := smalldb.Open[User](file)
+	defer reopened.Close()
+	if user, ok := reopened.Get("user:1"); !ok || user.Name != "Alice" {
+		t.Fatalf("Expected Alice, got %v (found: %v)", user, ok)
+	}
+}
+
+func TestWithTempDirAcrossFilesystems(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("/dev/shm", "smalldb")
+	if err != nil {
+		t.Skip("no tmpfs at /dev/shm to use as a second filesystem")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(t.TempDir(), "db.json")
+	db, _ := smalldb.Open[User](file, smalldb.WithTempDir(tmpDir))
+	if err := db.Set("user:1", User{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Failed to set value across filesystems: %v", err)
+	}
+	_ = db.Close()
+
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 0 {
+		t.Fatalf("Expected the temporary directory to be left empty, found %d entries", len(entries))
+	}
+	raw, _ := os.ReadFile(file)
+	if !strings.Contains(string(raw), "Alice") {
+		t.Fatalf("Expected the write to reach the database file, got %s", raw)
+	}
+}
+
+func TestWithTempDirShared(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := filepath.Join(t.TempDir(), "data.json")
+	second := filepath.Join(t.TempDir(), "data.json")
+
+	a, _ := smalldb.Open[User](first, smalldb.WithTempDir(tmpDir))
+	defer a.Close()
+	b, _ := smalldb.Open[User](second, smalldb.WithTempDir(tmpDir))
+	defer b.Close()
+
+	// A leftover with the old fixed name must not be mistaken for a live write.
+	_ = os.WriteFile(filepath.Join(tmpDir, "data.json.tmp"), []byte("leftover"), 0644)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_ = a.Set("user", User{Name: "Alice", Age: i})
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		_ = b.Set("user", User{Name: "Bob", Age: i})
+		_, _ = b.Compact()
+	}
+	<-done
+
+	for _, c := range []struct{ path, want, other string }{{first, "Alice", "Bob"}, {second, "Bob", "Alice"}} {
+		raw, _ := os.ReadFile(c.path)
+		if !strings.Contains(string(raw), c.want) || strings.Contains(string(raw), c.other) {
+			t.Fatalf("Expected %s to hold only %s, got %s", c.path, c.want, raw)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "data.json.tmp")); err != nil {
+		t.Fatalf("Expected Compact to leave other files in the temporary directory alone, got %v", err)
+	}
+}
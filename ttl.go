@@ -0,0 +1,150 @@
+package smalldb
+
+import (
+	"errors"
+	"time"
+)
+
+// SetWithTTL sets the value for the given key and expires it after ttl.
+// Expired keys are reported as missing, removed lazily on access and swept
+// periodically in the background. The expiry is persisted so it survives restarts.
+func (db *DB[T]) SetWithTTL(key string, value T, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("smalldb: ttl must be positive")
+	}
+
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+	if err := db.validateValue(key, value); err != nil {
+		return err
+	}
+	if err := db.checkChange(map[string]T{key: value}); err != nil {
+		return err
+	}
+
+	saved := db.saveForRollback(key)
+	db.data[key] = value
+	db.expires[key] = time.Now().Add(ttl)
+	db.startSweeper()
+	if err := db.commit([]Event[T]{{Key: key, Value: value, Op: OpSet}}, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
+}
+
+// Touch resets the expiry of an existing key to ttl from now, giving it a TTL
+// if it had none, and persists the change without altering the stored value.
+// It returns ErrKeyNotFound if the key is missing or has already expired.
+// Subscribers and OnSet hooks see the touch as a set of the unchanged value.
+func (db *DB[T]) Touch(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("smalldb: ttl must be positive")
+	}
+
+	db.mu.Lock()
+	defer db.unlock()
+
+	if err := db.writable(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	db.purgeExpired(now)
+	value, exists := db.data[key]
+	if !exists {
+		return ErrKeyNotFound
+	}
+
+	saved := db.saveForRollback(key)
+	db.expires[key] = now.Add(ttl)
+	db.startSweeper()
+	if err := db.commit([]Event[T]{{Key: key, Value: value, Op: OpSet}}, nil); err != nil {
+		saved.restore()
+		return err
+	}
+	return nil
+}
+
+// isExpired reports whether the key has an expiry at or before now.
+// The caller must hold db.mu.
+func (db *DB[T]) isExpired(key string, now time.Time) bool {
+	expiry, ok := db.expires[key]
+	return ok && !now.Before(expiry)
+}
+
+// purgeExpired removes all expired entries from memory and returns how many were removed.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) purgeExpired(now time.Time) int {
+	removed := 0
+	for key := range db.expires {
+		if db.isExpired(key, now) {
+			db.expire(key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// removeExpired drops a single key from memory if it is still expired.
+// The file is left untouched; expired entries are ignored when it is next loaded.
+func (db *DB[T]) removeExpired(key string) {
+	db.mu.Lock()
+	defer db.unlock()
+
+	if db.isExpired(key, time.Now()) {
+		db.expire(key)
+	}
+}
+
+// expire removes an expired key from memory, from the indexes and from
+// WithMaxEntries tracking, none of which see an event for it.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) expire(key string) {
+	delete(db.data, key)
+	delete(db.expires, key)
+	for _, idx := range db.indexes {
+		idx.remove(key)
+	}
+	if db.lru != nil {
+		db.lru.remove(key)
+	}
+}
+
+// startSweeper launches the background expiry sweep if it is enabled and not yet running.
+// The caller must hold db.mu for writing or have exclusive access to db.
+func (db *DB[T]) startSweeper() {
+	if db.sweeping || db.cfg.sweep <= 0 {
+		return
+	}
+
+	db.sweeping = true
+	db.wg.Add(1)
+	go db.sweep(db.cfg.sweep)
+}
+
+// sweep periodically purges expired entries and persists the result until the database is closed.
+// Read-only databases only purge their in-memory copy.
+func (db *DB[T]) sweep(interval time.Duration) {
+	defer db.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			db.mu.Lock()
+			if !db.closed && db.purgeExpired(time.Now()) > 0 && !db.cfg.readOnly {
+				_ = db.persist()
+			}
+			db.unlock()
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestSetWithTTL(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	if err := db.SetWithTTL("session:1", User{Name: "Bob", Age: 25}, 50*time.Millisecond); err != nil {
+		t.Fatalf("Failed to set data with TTL: %v", err)
+	}
+
+	if _, exists := db.Get("session:1"); !exists {
+		t.Fatalf("Expected key to exist before expiry")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, exists := db.Get("session:1"); exists {
+		t.Fatalf("Expected key to be expired")
+	}
+
+	if all := db.GetAll(); len(all) != 1 {
+		t.Fatalf("Expected expired key to be omitted from GetAll, got %v", all)
+	}
+}
+
+func TestTTLSurvivesReopen(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file)
+	defer db.Close()
+	_ = db.SetWithTTL("session:long", User{Name: "Alice"}, time.Hour)
+	_ = db.SetWithTTL("session:short", User{Name: "Bob"}, 50*time.Millisecond)
+	_ = db.Close()
+
+	time.Sleep(100 * time.Millisecond)
+
+	reopened, err := smalldb.Open[User](file)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+
+	if _, exists := reopened.Get("session:long"); !exists {
+		t.Fatalf("Expected unexpired key to survive reopen")
+	}
+	if _, exists := reopened.Get("session:short"); exists {
+		t.Fatalf("Expected expired key to be dropped on reopen")
+	}
+}
+
+func TestTTLBackgroundSweep(t *testing.T) {
+	file := "test_db.json"
+	defer cleanup(file)
+
+	db, _ := smalldb.Open[User](file, smalldb.WithSweepInterval(10*time.Millisecond))
+	defer db.Close()
+
+	_ = db.SetWithTTL("session:1", User{Name: "Bob"}, 20*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	contents, _ := os.ReadFile(file)
+	if strings.Contains(string(contents), "session:1") {
+		t.Fatalf("Expected sweep to remove expired key from disk, got %s", contents)
+	}
+}
+
+func TestTouchExtendsTTL(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.SetWithTTL("session:1", User{Name: "Alice"}, 50*time.Millisecond)
+
+	if err := db.Touch("session:1", time.Hour); err != nil {
+		t.Fatalf("Failed to touch key: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if user, ok := db.Get("session:1"); !ok || user.Name != "Alice" {
+		t.Fatalf("Expected the touched key to outlive its original TTL, got %v (found: %v)", user, ok)
+	}
+
+	if err := db.Touch("session:missing", time.Hour); !errors.Is(err, smalldb.ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
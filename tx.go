@@ -1,23 +1,175 @@
 package smalldb
 
+import "time"
+
 // Tx represents a transaction with exclusive access to the database.
+// Transactions started with View are read-only.
 type Tx[T any] struct {
-	db   *DB[T]
-	data map[string]T
+	db         *DB[T]
+	data       map[string]T
+	expires    map[string]time.Time
+	touched    map[string]struct{}
+	now        time.Time
+	readOnly   bool
+	rolledBack bool
+	savepoints []savepoint[T]
+}
+
+// savepoint is a copy of a transaction's working state.
+type savepoint[T any] struct {
+	data    map[string]T
+	expires map[string]time.Time
+	touched map[string]struct{}
 }
 
 // Get retrieves the value associated with the given key within the transaction.
 func (tx *Tx[T]) Get(key string) (T, bool) {
 	value, exists := tx.data[key]
-	return value, exists
+	if !exists || tx.isExpired(key) {
+		var zero T
+		return zero, false
+	}
+	return value, true
+}
+
+// GetDefault returns the value associated with the given key within the
+// transaction, or def if the key is missing.
+func (tx *Tx[T]) GetDefault(key string, def T) T {
+	if value, exists := tx.Get(key); exists {
+		return value
+	}
+	return def
+}
+
+// Has reports whether the given key exists within the transaction.
+func (tx *Tx[T]) Has(key string) bool {
+	_, exists := tx.data[key]
+	return exists && !tx.isExpired(key)
 }
 
-// Set sets the value for the given key within the transaction.
+// GetAll returns a copy of all key-value pairs as seen by the transaction,
+// including any changes already made within it.
+func (tx *Tx[T]) GetAll() map[string]T {
+	all := make(map[string]T, len(tx.data))
+	for k, v := range tx.data {
+		if tx.isExpired(k) {
+			continue
+		}
+		all[k] = v
+	}
+	return all
+}
+
+// Keys returns all keys as seen by the transaction, in no particular order.
+func (tx *Tx[T]) Keys() []string {
+	keys := make([]string, 0, len(tx.data))
+	for k := range tx.data {
+		if tx.isExpired(k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Set sets the value for the given key within the transaction, clearing any TTL the key had.
 func (tx *Tx[T]) Set(key string, value T) {
+	tx.checkWritable()
 	tx.data[key] = value
+	delete(tx.expires, key)
+	tx.touched[key] = struct{}{}
+}
+
+// Update replaces the value for the given key within the transaction with the
+// result of fn, which receives the current value, or the zero value and false
+// if the key is missing. Unlike Set, any TTL on the key is preserved.
+func (tx *Tx[T]) Update(key string, fn func(old T, exists bool) T) {
+	tx.checkWritable()
+	old, exists := tx.Get(key)
+	tx.data[key] = fn(old, exists)
+	tx.touched[key] = struct{}{}
 }
 
 // Delete removes the value associated with the given key within the transaction.
 func (tx *Tx[T]) Delete(key string) {
+	tx.checkWritable()
 	delete(tx.data, key)
+	delete(tx.expires, key)
+	tx.touched[key] = struct{}{}
+}
+
+// SetMany sets all of the given key-value pairs within the transaction,
+// clearing any TTLs they had.
+func (tx *Tx[T]) SetMany(items map[string]T) {
+	for k, v := range items {
+		tx.Set(k, v)
+	}
+}
+
+// DeleteMany removes all of the given keys within the transaction.
+func (tx *Tx[T]) DeleteMany(keys []string) {
+	for _, k := range keys {
+		tx.Delete(k)
+	}
+}
+
+// Rollback discards all changes made in the transaction.
+// The transaction returns without persisting anything once fn returns.
+func (tx *Tx[T]) Rollback() {
+	tx.rolledBack = true
+}
+
+// Savepoint records the current state of the transaction and returns an id
+// that RollbackTo can later return to.
+func (tx *Tx[T]) Savepoint() int {
+	tx.checkWritable()
+	tx.savepoints = append(tx.savepoints, savepoint[T]{
+		data:    cloneMap(tx.data),
+		expires: cloneMap(tx.expires),
+		touched: cloneMap(tx.touched),
+	})
+	return len(tx.savepoints) - 1
+}
+
+// RollbackTo reverts every change made since the savepoint with the given id
+// was created. Savepoints created after it are discarded; the savepoint itself
+// remains valid so it can be rolled back to again. It panics if id is unknown.
+func (tx *Tx[T]) RollbackTo(id int) {
+	if id < 0 || id >= len(tx.savepoints) {
+		panic("smalldb: unknown savepoint")
+	}
+
+	sp := tx.savepoints[id]
+	tx.data = cloneMap(sp.data)
+	tx.expires = cloneMap(sp.expires)
+	tx.touched = cloneMap(sp.touched)
+	tx.savepoints = tx.savepoints[:id+1]
+}
+
+// Transaction runs fn as a transaction nested in tx, joining its working set:
+// fn's changes are committed with the rest of tx, in a single persist, and
+// discarded on their own if fn returns an error, which Transaction returns.
+// Calling Rollback inside fn still discards the whole of tx.
+func (tx *Tx[T]) Transaction(fn func(tx *Tx[T]) error) error {
+	id := tx.Savepoint()
+	err := fn(tx)
+	if err != nil {
+		tx.RollbackTo(id)
+	}
+	tx.savepoints = tx.savepoints[:id]
+	return err
+}
+
+// isExpired reports whether the key had expired when the transaction started.
+// Only views can see expired entries; Transaction purges them up front.
+func (tx *Tx[T]) isExpired(key string) bool {
+	expiry, ok := tx.expires[key]
+	return ok && !tx.now.Before(expiry)
+}
+
+// checkWritable panics if the transaction is read-only.
+func (tx *Tx[T]) checkWritable() {
+	if tx.readOnly {
+		panic(ErrReadOnly)
+	}
 }
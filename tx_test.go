@@ -0,0 +1,295 @@
+package smalldb_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestTransactionRollback(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", User{Name: "Bob", Age: 25})
+		tx.Delete("user:1")
+		tx.Rollback()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected rolled back transaction to return nil, got %v", err)
+	}
+
+	if !db.Has("user:1") || db.Has("user:2") {
+		t.Fatalf("Expected rollback to discard changes, got %v", db.GetAll())
+	}
+}
+
+func TestView(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	err := db.View(func(tx *smalldb.Tx[User]) error {
+		if user, exists := tx.Get("user:1"); !exists || user.Name != "Alice" {
+			t.Errorf("Expected to read Alice, got %v", user)
+		}
+
+		defer func() {
+			if r := recover(); r != smalldb.ErrReadOnly {
+				t.Errorf("Expected Set to panic with ErrReadOnly, got %v", r)
+			}
+		}()
+		tx.Set("user:2", User{Name: "Bob"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View failed: %v", err)
+	}
+
+	if db.Has("user:2") {
+		t.Fatalf("Expected view not to modify the database")
+	}
+
+	abort := errors.New("abort")
+	if err := db.View(func(tx *smalldb.Tx[User]) error { return abort }); !errors.Is(err, abort) {
+		t.Fatalf("Expected View to return fn's error, got %v", err)
+	}
+}
+
+func TestTxGetAllAndKeys(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Delete("user:1")
+		tx.Set("user:3", User{Name: "Charlie", Age: 28})
+
+		all := tx.GetAll()
+		if len(all) != 2 || all["user:3"].Name != "Charlie" {
+			t.Errorf("Expected GetAll to reflect pending changes, got %v", all)
+		}
+
+		keys := tx.Keys()
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, []string{"user:2", "user:3"}) {
+			t.Errorf("Expected [user:2 user:3], got %v", keys)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+}
+
+func TestSavepoints(t *testing.T) {
+	db := smalldb.OpenMemory[User]()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", User{Name: "Bob", Age: 25})
+
+		sp := tx.Savepoint()
+		tx.Set("user:3", User{Name: "Charlie", Age: 28})
+		tx.Delete("user:1")
+		tx.RollbackTo(sp)
+
+		if tx.Has("user:3") || !tx.Has("user:1") {
+			t.Errorf("Expected savepoint rollback to revert later changes, got %v", tx.GetAll())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	keys := db.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"user:1", "user:2"}) {
+		t.Fatalf("Expected changes before the savepoint to commit, got %v", keys)
+	}
+}
+
+func TestTransactionRetry(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+
+	attempts := 0
+	err := db.TransactionRetry(3, func(tx *smalldb.Tx[int]) error {
+		attempts++
+		value, _ := tx.Get("counter")
+		tx.Set("counter", value+1)
+		if attempts < 3 {
+			return smalldb.ErrRetry
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the third attempt to succeed, got %v", err)
+	}
+	if value, _ := db.Get("counter"); value != 1 {
+		t.Fatalf("Expected only the successful attempt to commit, got %d", value)
+	}
+
+	attempts = 0
+	err = db.TransactionRetry(2, func(tx *smalldb.Tx[int]) error {
+		attempts++
+		return fmt.Errorf("conflict: %w", smalldb.ErrRetry)
+	})
+	if !errors.Is(err, smalldb.ErrRetry) || attempts != 2 {
+		t.Fatalf("Expected ErrRetry after 2 attempts, got %v after %d", err, attempts)
+	}
+}
+
+func TestTxUpdate(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.Set("a", 1)
+
+	err := db.Transaction(func(tx *smalldb.Tx[int]) error {
+		increment := func(old int, _ bool) int { return old + 1 }
+		tx.Update("a", increment)
+		tx.Update("b", increment)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{"a": 2, "b": 1}) {
+		t.Fatalf("Expected a=2 and b=1, got %v", got)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"a": 1, "b": 2})
+
+	changes, err := db.DryRun(func(tx *smalldb.Tx[int]) error {
+		tx.Set("a", 10)
+		tx.Delete("b")
+		tx.Set("c", 3)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+
+	want := smalldb.Changeset[int]{
+		Added:    map[string]int{"c": 3},
+		Removed:  map[string]int{"b": 2},
+		Modified: map[string]smalldb.Change[int]{"a": {Old: 1, New: 10}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Expected %+v, got %+v", want, changes)
+	}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{"a": 1, "b": 2}) {
+		t.Fatalf("Expected the dry run not to change anything, got %v", got)
+	}
+}
+
+func TestDryRunMatchesCommit(t *testing.T) {
+	db := smalldb.OpenMemory[int](smalldb.WithBeforeCommit(func(tx *smalldb.Tx[int]) error {
+		tx.Set("audit", 1)
+		return nil
+	}))
+	_ = db.Set("a", 1)
+	fn := func(tx *smalldb.Tx[int]) error {
+		tx.Delete("a")
+		tx.Set("b", 2)
+		return nil
+	}
+
+	changes, err := db.DryRun(fn)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	before := db.GetAll()
+	if err := db.Transaction(fn); err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if want := smalldb.Diff(before, db.GetAll()); !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Expected the dry run to report %+v, got %+v", want, changes)
+	}
+
+	file := filepath.Join(t.TempDir(), "db.json")
+	writer, _ := smalldb.Open[int](file)
+	_ = writer.Close()
+	readOnly, _ := smalldb.Open[int](file, smalldb.WithReadOnly())
+	defer readOnly.Close()
+	if _, err := readOnly.DryRun(fn); !errors.Is(err, smalldb.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly from a writing dry run, got %v", err)
+	}
+}
+
+func TestNestedTransaction(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.Set("a", 1)
+
+	err := db.Transaction(func(tx *smalldb.Tx[int]) error {
+		tx.Set("b", 2)
+		if err := tx.Transaction(func(tx *smalldb.Tx[int]) error {
+			tx.Set("c", 3)
+			return nil
+		}); err != nil {
+			t.Errorf("Expected the nested transaction to succeed, got %v", err)
+		}
+		failed := errors.New("failed")
+		if err := tx.Transaction(func(tx *smalldb.Tx[int]) error {
+			tx.Delete("a")
+			tx.Set("d", 4)
+			return failed
+		}); !errors.Is(err, failed) {
+			t.Errorf("Expected the nested transaction's error, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{"a": 1, "b": 2, "c": 3}) {
+		t.Fatalf("Expected only the failed nested transaction to be discarded, got %v", got)
+	}
+}
+
+func TestRollbackToForgetsTouchedKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	writer, _ := smalldb.Open[int](file)
+	_ = writer.Set("a", 1)
+	_ = writer.Close()
+
+	db, _ := smalldb.Open[int](file, smalldb.WithReadOnly())
+	defer db.Close()
+	err := db.Transaction(func(tx *smalldb.Tx[int]) error {
+		sp := tx.Savepoint()
+		tx.Set("a", 2)
+		tx.RollbackTo(sp)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected a transaction rolled back to its start to write nothing, got %v", err)
+	}
+}
+
+func TestTxSetManyAndDeleteMany(t *testing.T) {
+	db := smalldb.OpenMemory[int]()
+	_ = db.SetMany(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	err := db.Transaction(func(tx *smalldb.Tx[int]) error {
+		tx.SetMany(map[string]int{"a": 10, "d": 4})
+		tx.DeleteMany([]string{"b", "c", "missing"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+
+	if got := db.GetAll(); !reflect.DeepEqual(got, map[string]int{"a": 10, "d": 4}) {
+		t.Fatalf("Expected a=10 and d=4, got %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package smalldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WithValidator registers fn to check every value before it is written.
+// Set, SetMany, Update and the other write methods return fn's error without
+// changing anything when it rejects a value, and a Transaction is rejected at
+// commit time if any value it set fails validation.
+// T must match the value type of the database being opened.
+func WithValidator[T any](fn func(key string, value T) error) Option {
+	return func(c *config) {
+		c.validator = fn
+	}
+}
+
+// WithKeyValidator registers fn to check every key before a value is written
+// under it. Set, SetMany, Rename and the other write methods return fn's error
+// without changing anything when it rejects a key, and a Transaction is
+// rejected at commit time if it set any key that fails validation. Keys are
+// not checked when they are deleted or read, or when the file is loaded.
+func WithKeyValidator(fn func(key string) error) Option {
+	return func(c *config) {
+		c.keyValidator = fn
+	}
+}
+
+// validateValue runs the configured key and value validators, if any, on a single entry.
+func (db *DB[T]) validateValue(key string, value T) error {
+	if db.cfg.keyValidator != nil {
+		if err := db.cfg.keyValidator(key); err != nil {
+			return err
+		}
+	}
+	if db.validator == nil {
+		return nil
+	}
+	return db.validator(key, value)
+}
+
+// validateKeys runs the configured key and value validators on the given keys
+// of data in key order, skipping keys that are not present. It returns the
+// first error.
+func (db *DB[T]) validateKeys(data map[string]T, keys map[string]struct{}) error {
+	if db.validator == nil && db.cfg.keyValidator == nil {
+		return nil
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		value, exists := data[k]
+		if !exists {
+			continue
+		}
+		if err := db.validateValue(k, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithMarshalCheck makes Open encode a zero value of T with the configured
+// codec and fail if that is not possible, such as when T has a channel or
+// function field, so the problem shows up at startup rather than on the first
+// write. Fields that are nil in the zero value, like pointers and interfaces,
+// are not checked.
+func WithMarshalCheck() Option {
+	return func(c *config) {
+		c.marshalCheck = true
+	}
+}
+
+// checkMarshal reports whether a zero value of T can be encoded with cfg's
+// codec, or with JSON for the JSONLines format.
+func checkMarshal[T any](cfg *config) error {
+	var zero T
+	var err error
+	if cfg.format == JSONLines {
+		_, err = json.Marshal(zero)
+	} else {
+		_, err = cfg.codec.Marshal(map[string]T{"": zero})
+	}
+	if err != nil {
+		return fmt.Errorf("smalldb: values of type %v cannot be encoded: %w", reflect.TypeFor[T](), err)
+	}
+	return nil
+}
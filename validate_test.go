@@ -0,0 +1,110 @@
+package smalldb_test
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+var errInvalidAge = errors.New("age must not be negative")
+
+func validUser(_ string, u User) error {
+	if u.Age < 0 {
+		return errInvalidAge
+	}
+	return nil
+}
+
+func TestValidatorRejectsWrites(t *testing.T) {
+	db := smalldb.OpenMemory[User](smalldb.WithValidator(validUser))
+
+	if err := db.Set("user:1", User{Name: "Alice", Age: -1}); !errors.Is(err, errInvalidAge) {
+		t.Fatalf("Expected validation error from Set, got %v", err)
+	}
+	err := db.SetMany(map[string]User{
+		"user:2": {Name: "Bob", Age: 25},
+		"user:3": {Name: "Charlie", Age: -3},
+	})
+	if !errors.Is(err, errInvalidAge) {
+		t.Fatalf("Expected validation error from SetMany, got %v", err)
+	}
+	if db.Len() != 0 {
+		t.Fatalf("Expected nothing to be stored, got %v", db.GetAll())
+	}
+
+	if err := db.Set("user:1", User{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Expected valid value to be stored, got %v", err)
+	}
+}
+
+func TestValidatorRunsOnTransactionCommit(t *testing.T) {
+	db := smalldb.OpenMemory[User](smalldb.WithValidator(validUser))
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:1", User{Name: "Alice", Age: -30})
+		tx.Set("user:2", User{Name: "Bob", Age: 25})
+		return nil
+	})
+	if !errors.Is(err, errInvalidAge) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+
+	if value, _ := db.Get("user:1"); value.Age != 30 {
+		t.Fatalf("Expected user:1 to be unchanged, got %v", value)
+	}
+	if db.Has("user:2") {
+		t.Fatalf("Expected user:2 not to be committed")
+	}
+}
+
+func TestMarshalCheck(t *testing.T) {
+	type withChannel struct {
+		Name    string
+		Updates chan string
+	}
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	if _, err := smalldb.Open[withChannel](file, smalldb.WithMarshalCheck()); err == nil {
+		t.Fatal("Expected Open to reject a value type with a channel field")
+	}
+
+	db, err := smalldb.Open[User](file, smalldb.WithMarshalCheck())
+	if err != nil {
+		t.Fatalf("Expected Open to accept an encodable value type, got %v", err)
+	}
+	db.Close()
+}
+
+func TestKeyValidator(t *testing.T) {
+	errBadKey := errors.New("keys must look like user:N")
+	userKey := regexp.MustCompile(`^user:[0-9]+$`)
+	db := smalldb.OpenMemory[User](smalldb.WithKeyValidator(func(key string) error {
+		if !userKey.MatchString(key) {
+			return errBadKey
+		}
+		return nil
+	}))
+
+	if err := db.Set("usr:1", User{Name: "Alice"}); !errors.Is(err, errBadKey) {
+		t.Fatalf("Expected key validation error from Set, got %v", err)
+	}
+	_ = db.Set("user:1", User{Name: "Alice"})
+	if err := db.Rename("user:1", "user-1"); !errors.Is(err, errBadKey) {
+		t.Fatalf("Expected key validation error from Rename, got %v", err)
+	}
+	err := db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("USER:2", User{Name: "Bob"})
+		return nil
+	})
+	if !errors.Is(err, errBadKey) {
+		t.Fatalf("Expected key validation error from Transaction, got %v", err)
+	}
+
+	if keys := db.Keys(); len(keys) != 1 || keys[0] != "user:1" {
+		t.Fatalf("Expected only user:1 to be stored, got %v", keys)
+	}
+}
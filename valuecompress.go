@@ -0,0 +1,104 @@
+package smalldb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+)
+
+// compressedKey names the only field of the object that replaces a value
+// compressed by WithValueCompression.
+const compressedKey = "$gzip"
+
+// compressedValue is the marker stored in place of a compressed value.
+// The gzipped JSON is base64 encoded by encoding/json.
+type compressedValue struct {
+	Gzip []byte `json:"$gzip"`
+}
+
+// WithValueCompression gzips every value whose JSON encoding is at least
+// minBytes long, storing it as {"$gzip": "<base64>"} in place of the value.
+// Smaller values are stored as is, so the file stays readable where it can;
+// a minBytes of zero or less compresses every value. A value that is itself an
+// object with only a "$gzip" field is always compressed, so it is never
+// mistaken for one that was.
+// Unlike WithCompression, which compresses the whole file, this keeps keys and
+// small values visible while shrinking large documents.
+//
+// The values are compressed in memory before the file is written. Files
+// holding compressed values must be opened with this option to be read, and
+// it requires the default JSON codec and format.
+func WithValueCompression(minBytes int) Option {
+	return func(c *config) {
+		c.valueCompression = max(minBytes, 1)
+	}
+}
+
+// streamCompressed writes env like streamJSON after compressing every value
+// whose encoding is at least minBytes long.
+func streamCompressed[T any](w io.Writer, env *envelope[T], codec JSONCodec, minBytes int) error {
+	packed := &envelope[json.RawMessage]{
+		Format:  env.Format,
+		Version: env.Version,
+		Data:    make(map[string]json.RawMessage, len(env.Data)),
+		Expires: env.Expires,
+	}
+	for k, v := range env.Data {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if len(encoded) >= minBytes || isCompressedMarker(encoded) {
+			if encoded, err = compressValue(encoded); err != nil {
+				return err
+			}
+		}
+		packed.Data[k] = encoded
+	}
+	return streamJSON(w, packed, codec)
+}
+
+// compressValue gzips the encoded value and wraps it in the compressed marker.
+func compressValue(encoded []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(encoded); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(compressedValue{Gzip: buf.Bytes()})
+}
+
+// isCompressedMarker reports whether raw is an object whose only field is
+// compressedKey, the shape of a compressed value.
+func isCompressedMarker(raw []byte) bool {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !bytes.Contains(trimmed, []byte(`"`+compressedKey+`"`)) {
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil || len(fields) != 1 {
+		return false
+	}
+	_, ok := fields[compressedKey]
+	return ok
+}
+
+// decompressValue returns the JSON held by a compressed marker, or raw
+// unchanged if it is an ordinary value. Values shaped like the marker are
+// always compressed when written, so every marker read back is a real one.
+func decompressValue(raw json.RawMessage) (json.RawMessage, error) {
+	if !isCompressedMarker(raw) {
+		return raw, nil
+	}
+
+	var marker compressedValue
+	if err := json.Unmarshal(raw, &marker); err != nil {
+		return nil, err
+	}
+	return gunzipBytes(marker.Gzip)
+}
@@ -0,0 +1,70 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestValueCompression(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	long := strings.Repeat("Wonderland ", 100)
+
+	db, err := smalldb.Open[User](file, smalldb.WithValueCompression(256))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_ = db.Set("small", User{Name: "Alice", Age: 30})
+	_ = db.Set("large", User{Name: long, Age: 31})
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	raw, _ := os.ReadFile(file)
+	if !strings.Contains(string(raw), `"$gzip"`) || strings.Contains(string(raw), "Wonderland") {
+		t.Fatalf("Expected the large value to be compressed, got %s", raw)
+	}
+	if !strings.Contains(string(raw), `"Alice"`) {
+		t.Fatalf("Expected the small value to be stored as is, got %s", raw)
+	}
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithValueCompression(256))
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+	if user, _ := reopened.Get("large"); user.Name != long || user.Age != 31 {
+		t.Fatalf("Expected the large value to round-trip, got %+v", user)
+	}
+	if user, _ := reopened.Get("small"); user.Name != "Alice" {
+		t.Fatalf("Expected the small value to round-trip, got %+v", user)
+	}
+
+	if _, err := smalldb.Open[User](filepath.Join(t.TempDir(), "db.gob"), smalldb.WithValueCompression(1), smalldb.WithCodec(smalldb.GobCodec{})); err == nil {
+		t.Fatalf("Expected WithValueCompression to require the JSON codec")
+	}
+}
+
+func TestValueCompressionKeepsMarkerShapedValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	value := map[string]string{"$gzip": "not compressed"}
+
+	db, _ := smalldb.Open[map[string]string](file, smalldb.WithValueCompression(1024))
+	_ = db.Set("tricky", value)
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := smalldb.Open[map[string]string](file, smalldb.WithValueCompression(1024))
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer reopened.Close()
+	if got, _ := reopened.Get("tricky"); !reflect.DeepEqual(got, value) {
+		t.Fatalf("Expected the marker-shaped value to round-trip, got %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package smalldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// Verify checks the database file on disk without loading it into memory: the
+// checksum must be present and match when WithChecksum is set, the file must
+// decrypt and decompress, every value must decode into T, and every expiry
+// must be a valid time for a stored key. With WithWAL the log is checked too.
+// It returns nil if the file is sound, or one error per problem found joined
+// with errors.Join, each wrapping ErrCorrupted or ErrInvalidFormat where it
+// applies. Values are checked one by one only with the default JSON codec and
+// format; otherwise the first problem is reported. In-memory databases have
+// nothing to verify.
+func (db *DB[T]) Verify() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+	if db.cfg.memory {
+		return nil
+	}
+	return verifyFile[T](db.filepath, &db.cfg)
+}
+
+// verifyFile checks the file at fp, and its write-ahead log, as Verify describes.
+func verifyFile[T any](fp string, cfg *config) error {
+	raw, err := storageFor(fp, cfg).Read()
+	if errors.Is(err, fs.ErrNotExist) {
+		raw, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var problems []error
+	if len(raw) > 0 {
+		problems = verifyContents[T](raw, cfg)
+	}
+	if cfg.wal {
+		env := &envelope[T]{Data: make(map[string]T), Expires: make(map[string]time.Time)}
+		if _, _, err := replayWAL(fp+".wal", env); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// verifyContents checks the stored bytes of a database file.
+func verifyContents[T any](raw []byte, cfg *config) []error {
+	var problems []error
+	if cfg.checksum && !hasChecksum(raw) {
+		problems = append(problems, fmt.Errorf("%w: checksum header missing", ErrCorrupted))
+	}
+
+	payload, err := unpack(raw, cfg)
+	if err != nil {
+		return append(problems, err)
+	}
+
+	if _, ok := cfg.codec.(JSONCodec); ok && cfg.format == JSON {
+		return append(problems, verifyJSON[T](payload, cfg)...)
+	}
+	if _, err := decodeData[T](payload, cfg); err != nil {
+		problems = append(problems, err)
+	}
+	return problems
+}
+
+// verifyJSON checks every value and expiry in a JSON database, in key order.
+func verifyJSON[T any](raw []byte, cfg *config) []error {
+	var file struct {
+		Format  int                        `json:"$smalldb"`
+		Version int                        `json:"version"`
+		Data    map[string]json.RawMessage `json:"data"`
+		Expires map[string]json.RawMessage `json:"expires"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil || file.Format != envelopeFormat {
+		file.Version, file.Data, file.Expires = 0, nil, nil
+		if err := json.Unmarshal(raw, &file.Data); err != nil {
+			return []error{diagnoseJSON[T](raw, err)}
+		}
+	}
+
+	if file.Version > cfg.schemaVersion {
+		return []error{fmt.Errorf("smalldb: file schema version %d is newer than supported version %d", file.Version, cfg.schemaVersion)}
+	}
+
+	var problems []error
+	for _, k := range sortedKeys(file.Data) {
+		if _, err := decodeValue[T](k, file.Data[k], file.Version, cfg); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	for _, k := range sortedKeys(file.Expires) {
+		var expiry time.Time
+		if err := json.Unmarshal(file.Expires[k], &expiry); err != nil {
+			problems = append(problems, fmt.Errorf("%w: expiry of key %q: %v", ErrInvalidFormat, k, err))
+		} else if _, ok := file.Data[k]; !ok {
+			problems = append(problems, fmt.Errorf("%w: expiry for missing key %q", ErrInvalidFormat, k))
+		}
+	}
+	return problems
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
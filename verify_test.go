@@ -0,0 +1,72 @@
+package smalldb_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestVerify(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, err := smalldb.Open[User](file, smalldb.WithChecksum())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	if err := db.Verify(); err != nil {
+		t.Fatalf("Expected a sound file to verify, got %v", err)
+	}
+
+	contents, _ := os.ReadFile(file)
+	contents[len(contents)-3] ^= 0xff
+	_ = os.WriteFile(file, contents, 0644)
+	if err := db.Verify(); !errors.Is(err, smalldb.ErrCorrupted) {
+		t.Fatalf("Expected ErrCorrupted for a damaged file, got %v", err)
+	}
+	if user, _ := db.Get("user:1"); user.Name != "Alice" {
+		t.Fatalf("Expected Verify not to change the loaded data, got %+v", user)
+	}
+}
+
+func TestVerifyReportsEveryProblem(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, err := smalldb.Open[User](file)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	_ = os.WriteFile(file, []byte(`{
+  "$smalldb": 1,
+  "data": {
+    "user:1": {"name": "Alice", "age": 30},
+    "user:2": {"name": "Bob", "age": "old"},
+    "user:3": "Charlie"
+  },
+  "expires": {
+    "user:1": "tomorrow",
+    "user:4": "2030-01-01T00:00:00Z"
+  }
+}`), 0644)
+
+	err = db.Verify()
+	if !errors.Is(err, smalldb.ErrInvalidFormat) {
+		t.Fatalf("Expected ErrInvalidFormat, got %v", err)
+	}
+	for _, want := range []string{`"user:2"`, `"user:3"`, `expiry of key "user:1"`, `missing key "user:4"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the report to mention %s, got %v", want, err)
+		}
+	}
+	if db.Len() != 0 {
+		t.Fatalf("Expected Verify not to load the file, got %d entries", db.Len())
+	}
+}
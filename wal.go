@@ -0,0 +1,231 @@
+package smalldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// walCompactThreshold is the number of WAL records after which the snapshot
+// is rewritten and the log truncated.
+const walCompactThreshold = 1000
+
+// WithWAL appends each change to a write-ahead log at <path>.wal, synced to
+// disk before the write returns (see WithSync), instead of rewriting the whole
+// file. Writes then cost time proportional to the change rather than the
+// dataset. The full file is rewritten and the log truncated every 1000 records,
+// on Flush and on Compact; Open and Reload replay the log on top of the file,
+// and a record torn by a crash is cut off. WithAsyncPersist has no effect in this mode.
+// The log is plain JSON, so WithWAL cannot be combined with WithEncryption.
+func WithWAL() Option {
+	return func(c *config) {
+		c.wal = true
+	}
+}
+
+// walEntry records the state of a single key after a change.
+type walEntry[T any] struct {
+	Key     string     `json:"key"`
+	Value   T          `json:"value,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+	Deleted bool       `json:"deleted,omitempty"`
+}
+
+// walLog is an open write-ahead log.
+type walLog struct {
+	path    string
+	file    *os.File
+	records int
+	sync    SyncMode
+}
+
+// logEvents appends the state of every key in events to the write-ahead log
+// as a single record, compacting once the log grows past walCompactThreshold.
+// The caller must hold db.mu for writing.
+func (db *DB[T]) logEvents(events []Event[T]) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	entries := make([]walEntry[T], len(events))
+	for i, e := range events {
+		entries[i] = walEntry[T]{Key: e.Key, Value: e.Value, Deleted: e.Op == OpDelete}
+		if expiry, ok := db.expires[e.Key]; ok && e.Op == OpSet {
+			entries[i].Expires = &expiry
+		}
+	}
+
+	record, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	if err := db.wal.append(append(record, '\n'), db.cfg.fileMode); err != nil {
+		return err
+	}
+	db.counters.countPersist(start)
+
+	if db.wal.records >= walCompactThreshold {
+		_ = db.flush() // The change is durable in the log; a failed compaction is retried later.
+	}
+	return nil
+}
+
+// append writes record to the end of the log and syncs it as the SyncMode requires.
+func (w *walLog) append(record []byte, mode os.FileMode) error {
+	if w.file == nil {
+		file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode)
+		if err != nil {
+			return err
+		}
+		if err := syncDirOf(w.path, w.sync); err != nil {
+			file.Close()
+			return err
+		}
+		w.file = file
+	}
+
+	if _, err := w.file.Write(record); err != nil {
+		return err
+	}
+	if err := syncFile(w.file, w.sync); err != nil {
+		return err
+	}
+	w.records++
+	return nil
+}
+
+// truncate empties the log once its records are part of the snapshot.
+func (w *walLog) truncate() error {
+	if w.file != nil {
+		if err := w.file.Truncate(0); err != nil {
+			return err
+		}
+	} else if err := os.Truncate(w.path, 0); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	w.records = 0
+	return nil
+}
+
+// close closes the log file, if it is open.
+func (w *walLog) close() error {
+	if w == nil || w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// replayWAL applies the records of the log at path to env, returning how many
+// were applied and the length of the log up to the end of the last intact
+// record. Records describe the full state of each key, so replaying records
+// already contained in the snapshot is harmless. A torn final record is
+// skipped.
+func replayWAL[T any](path string, env *envelope[T]) (int, int64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	records, end := 0, 0
+	for offset, lineNo := 0, 1; offset < len(raw); lineNo++ {
+		line, next := raw[offset:], len(raw)
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line, next = line[:i], offset+i+1
+		}
+		offset = next
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entries []walEntry[T]
+		if err := json.Unmarshal(line, &entries); err != nil {
+			if len(bytes.TrimSpace(raw[next:])) == 0 {
+				break
+			}
+			return 0, 0, fmt.Errorf("%w: write-ahead log record %d: %v", ErrCorrupted, lineNo, err)
+		}
+
+		for _, e := range entries {
+			delete(env.Expires, e.Key)
+			if e.Deleted {
+				delete(env.Data, e.Key)
+				continue
+			}
+			env.Data[e.Key] = e.Value
+			if e.Expires != nil {
+				env.Expires[e.Key] = *e.Expires
+			}
+		}
+		records++
+		end = next
+	}
+	return records, int64(end), nil
+}
+
+// repairWAL cuts the log at path down to its first end bytes, dropping a record
+// torn by a crash, and makes sure it ends in a newline, so the next record is
+// appended on a line of its own rather than onto the damaged one.
+func repairWAL(path string, end int64, mode SyncMode) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if end > 0 {
+		if _, err := file.ReadAt(last, end-1); err != nil {
+			return err
+		}
+	}
+	if info.Size() == end && (end == 0 || last[0] == '\n') {
+		return nil
+	}
+
+	if err := file.Truncate(end); err != nil {
+		return err
+	}
+	if end > 0 && last[0] != '\n' {
+		if _, err := file.WriteAt([]byte{'\n'}, end); err != nil {
+			return err
+		}
+	}
+	return syncFile(file, mode)
+}
+
+// loadData reads the database file and, with WithWAL, replays the log on top
+// of it, cutting off a torn final record unless the database is read-only.
+// It returns the number of log records replayed.
+func loadData[T any](fp string, cfg *config) (*envelope[T], int, error) {
+	env, err := readData[T](fp, cfg)
+	if err != nil || !cfg.wal {
+		return env, 0, err
+	}
+
+	records, end, err := replayWAL(fp+".wal", env)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !cfg.readOnly {
+		if err := repairWAL(fp+".wal", end, cfg.sync); err != nil {
+			return nil, 0, err
+		}
+	}
+	return env, records, nil
+}
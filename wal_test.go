@@ -0,0 +1,120 @@
+package smalldb_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crazywolf132/smalldb"
+)
+
+func TestWALReplay(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+	_ = db.Delete("user:1")
+
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("Expected writes to go to the log only, got %v", err)
+	}
+	_ = db.Close()
+
+	// Simulate a crash in the middle of appending a record.
+	f, _ := os.OpenFile(file+".wal", os.O_WRONLY|os.O_APPEND, 0644)
+	_, _ = f.WriteString(`[{"key":"user:3","val`)
+	_ = f.Close()
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithWAL())
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	if reopened.Len() != 1 || !reopened.Has("user:2") {
+		t.Fatalf("Expected only user:2 after replay, got %v", reopened.GetAll())
+	}
+}
+
+func TestCompact(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	defer db.Close()
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+
+	if _, err := db.Compact(); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+
+	if info, err := os.Stat(file + ".wal"); err != nil || info.Size() != 0 {
+		t.Fatalf("Expected an empty log after compaction, got %v", err)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("Expected the snapshot to be written, got %v", err)
+	}
+
+	_ = db.Set("user:2", User{Name: "Bob", Age: 25})
+	_ = db.Close()
+
+	reopened, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	defer reopened.Close()
+	if reopened.Len() != 2 {
+		t.Fatalf("Expected snapshot and log to combine, got %v", reopened.GetAll())
+	}
+}
+
+func TestWALTornRecordThenMoreWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+
+	db, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	_ = db.Set("user:1", User{Name: "Alice", Age: 30})
+	_ = db.Close()
+
+	// Simulate a crash part way through appending a record.
+	wal, _ := os.OpenFile(file+".wal", os.O_WRONLY|os.O_APPEND, 0644)
+	_, _ = wal.WriteString(`[{"key":"user:2","val`)
+	_ = wal.Close()
+
+	db, err := smalldb.Open[User](file, smalldb.WithWAL())
+	if err != nil {
+		t.Fatalf("Expected the torn record to be skipped, got %v", err)
+	}
+	_ = db.Set("user:3", User{Name: "Charlie", Age: 28})
+	_ = db.Set("user:4", User{Name: "Dana", Age: 41})
+	_ = db.Close()
+
+	reopened, err := smalldb.Open[User](file, smalldb.WithWAL())
+	if err != nil {
+		t.Fatalf("Failed to reopen after writing past a torn record: %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 3 || reopened.Has("user:2") {
+		t.Fatalf("Expected user:1, user:3 and user:4, got %v", reopened.GetAll())
+	}
+}
+
+func TestWALLogsExpiryOnlyChanges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "db.json")
+	alice := User{Name: "Alice", Age: 30}
+
+	db, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	_ = db.SetWithTTL("user:1", alice, 100*time.Millisecond)
+	_ = db.SetMany(map[string]User{"user:1": alice}) // Clears the TTL.
+	_ = db.SetWithTTL("user:2", alice, 100*time.Millisecond)
+	_ = db.Transaction(func(tx *smalldb.Tx[User]) error {
+		tx.Set("user:2", alice)
+		return nil
+	})
+	_ = db.Close()
+
+	time.Sleep(150 * time.Millisecond)
+
+	reopened, _ := smalldb.Open[User](file, smalldb.WithWAL())
+	defer reopened.Close()
+	if !reopened.Has("user:1") || !reopened.Has("user:2") {
+		t.Fatalf("Expected the cleared TTLs to be logged, got %v", reopened.GetAll())
+	}
+}